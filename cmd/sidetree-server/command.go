@@ -0,0 +1,138 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/trustbloc/sidetree-mock/pkg/state"
+)
+
+const usage = `usage:
+  sidetree-mock                           start the sidetree node
+  sidetree-mock snapshot export <archive> export the state of the node to the archive
+  sidetree-mock snapshot import <archive> replace the state of the node with the state in the archive
+
+snapshot commands operate on the persistent stores configured with SIDETREE_MOCK_OPSTORE_PATH
+(or the operation store paths of the namespaces), SIDETREE_MOCK_CAS_PATH, SIDETREE_MOCK_LEDGER_PATH
+and SIDETREE_MOCK_OBSERVER_CURSOR_PATH and must not be run while the node is running. Operations in
+the persistent operation queues (SIDETREE_MOCK_OPQUEUE_PATH or the operation queue paths of the namespaces)
+are discarded on import`
+
+// runCommand runs the command given on the command line
+func runCommand(args []string) error {
+	if len(args) != 3 || args[0] != "snapshot" {
+		return errors.New(usage)
+	}
+
+	switch args[1] {
+	case "export":
+		return exportSnapshot(args[2])
+	case "import":
+		return importSnapshot(args[2])
+	default:
+		return errors.New(usage)
+	}
+}
+
+func exportSnapshot(archive string) (err error) {
+	stateManager, err := newPersistentStateManager()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Clean(archive))
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = fmt.Errorf("close archive: %w", e)
+		}
+	}()
+
+	return stateManager.Export(f)
+}
+
+func importSnapshot(archive string) error {
+	stateManager, err := newPersistentStateManager()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filepath.Clean(archive))
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	return stateManager.Import(f)
+}
+
+// newPersistentStateManager returns a state manager for the configured persistent stores
+func newPersistentStateManager() (*state.Manager, error) {
+	for _, key := range []string{"cas.path", "ledger.path", "observer.cursor.path"} {
+		if config.GetString(key) == "" {
+			return nil, fmt.Errorf("%s must be configured for snapshot commands", key)
+		}
+	}
+
+	namespaces, err := getNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	opStores := make(map[string]state.OperationStore, len(namespaces))
+
+	var opQueues []state.OperationQueue
+
+	for _, ns := range namespaces {
+		if ns.OperationStorePath == "" {
+			return nil, fmt.Errorf("operation store path of namespace [%s] must be configured for snapshot commands",
+				ns.Namespace)
+		}
+
+		opStore, e := newOperationStore(ns.OperationStorePath)
+		if e != nil {
+			return nil, e
+		}
+
+		opStores[ns.Namespace] = opStore
+
+		// the queued operations are discarded on import so persistent operation queues are opened as well
+		if ns.OperationQueuePath != "" {
+			opQueue, e := newOperationQueue(ns.OperationQueuePath)
+			if e != nil {
+				return nil, e
+			}
+
+			opQueues = append(opQueues, opQueue)
+		}
+	}
+
+	casClient, err := newCasClient()
+	if err != nil {
+		return nil, err
+	}
+
+	anchorWriter, err := newAnchorWriter(namespaces[0].Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := newObserverCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateManager(opStores, casClient, anchorWriter, cursor, opQueues, nil, nil), nil
+}
@@ -8,27 +8,38 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
-	"github.com/trustbloc/sidetree-core-go/pkg/batch"
-	"github.com/trustbloc/sidetree-core-go/pkg/dochandler"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-core-go/pkg/document"
-	coremocks "github.com/trustbloc/sidetree-core-go/pkg/mocks"
-	"github.com/trustbloc/sidetree-core-go/pkg/processor"
 	restcommon "github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
-	"github.com/trustbloc/sidetree-core-go/pkg/restapi/diddochandler"
-	sidetreecontext "github.com/trustbloc/sidetree-mock/pkg/context"
+	adminrest "github.com/trustbloc/sidetree-mock/pkg/admin/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/batchwriter"
+	casrest "github.com/trustbloc/sidetree-mock/pkg/cas/endpoint/restapi"
 	discoveryrest "github.com/trustbloc/sidetree-mock/pkg/discovery/endpoint/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/events"
+	eventsrest "github.com/trustbloc/sidetree-mock/pkg/events/endpoint/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/history"
 	"github.com/trustbloc/sidetree-mock/pkg/httpserver"
 	"github.com/trustbloc/sidetree-mock/pkg/mocks"
 	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	"github.com/trustbloc/sidetree-mock/pkg/opqueue"
+	"github.com/trustbloc/sidetree-mock/pkg/protocolconfig"
+	"github.com/trustbloc/sidetree-mock/pkg/representation"
+	"github.com/trustbloc/sidetree-mock/pkg/state"
+	unirest "github.com/trustbloc/sidetree-mock/pkg/uniresolver/endpoint/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/wait"
+	"github.com/trustbloc/sidetree-mock/pkg/webhook"
 )
 
 var logger = logrus.New()
@@ -41,85 +52,174 @@ const resolutionPath = "/sidetree/v1/identifiers"
 
 const arrayDelimiter = ","
 
+const defaultObserverPollInterval = 500 * time.Millisecond
+
+// ledger modes
+const (
+	// anchors become visible automatically (after the configured block time)
+	ledgerModeAuto = "auto"
+	// anchors become visible as soon as they are written and are processed by the observer before the batch
+	// writer continues
+	ledgerModeSync = "sync"
+	// anchors become visible only when blocks are mined using the admin API
+	ledgerModeManual = "manual"
+)
+
 func main() {
 	config.SetEnvPrefix("SIDETREE_MOCK")
 	config.AutomaticEnv()
 	config.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			logger.Errorf("Command failed: %s", err.Error())
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	logger.Info("starting sidetree node...")
 
-	opStore := mocks.NewMockOperationStore()
+	casClient, err := newCasClient()
+	if err != nil {
+		logger.Errorf("Failed to create CAS client: %s", err.Error())
+		panic(err)
+	}
 
-	didDocNamespace := defaultDIDDocNamespace
+	namespaces, err := getNamespaces()
+	if err != nil {
+		logger.Errorf("Failed to load namespaces: %s", err.Error())
+		panic(err)
+	}
 
-	if config.GetString("did.namespace") != "" {
-		didDocNamespace = config.GetString("did.namespace")
+	// transactions that don't specify a namespace are anchored in the first namespace
+	anchorWriter, err := newAnchorWriter(namespaces[0].Namespace)
+	if err != nil {
+		logger.Errorf("Failed to create anchor writer: %s", err.Error())
+		panic(err)
 	}
 
-	var aliases []string
-	if config.GetString("did.aliases") != "" {
-		aliases = strings.Split(config.GetString("did.aliases"), arrayDelimiter)
+	batchOpts, err := getBatchOptions()
+	if err != nil {
+		logger.Errorf("Failed to load batch policy: %s", err.Error())
+		panic(err)
 	}
 
-	var methodCtx []string
-	if config.GetString("did.method.context") != "" {
-		methodCtx = strings.Split(config.GetString("did.method.context"), arrayDelimiter)
+	webhookOpts, err := getWebhookOptions()
+	if err != nil {
+		logger.Errorf("Failed to load webhook settings: %s", err.Error())
+		panic(err)
 	}
 
-	baseEnabled := false
-	if config.GetString("did.base.enabled") != "" {
-		baseEnabled = config.GetBool("did.base.enabled")
+	// webhooks are notified when operations are queued and anchored by the batch writers and when they are
+	// processed into the operation stores by the observer
+	webhooks := webhook.New(append(webhookOpts, webhook.WithLedger(anchorWriter))...)
+
+	eventOpts, err := getEventOptions()
+	if err != nil {
+		logger.Errorf("Failed to load event settings: %s", err.Error())
+		panic(err)
 	}
 
-	pcp := mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithOpStoreClient(opStore).WithMethodContext(methodCtx).WithBase(baseEnabled)
-	pc, err := pcp.ForNamespace(mocks.DefaultNS)
+	// the event broker streams the events of the batch writers, the observer and the operation stores
+	broker := events.New(append(eventOpts, events.WithLedger(anchorWriter))...)
+
+	batchOpts = append(batchOpts, batchwriter.WithListener(webhooks), batchwriter.WithListener(broker))
+
+	waitOpts, err := getWaitOptions()
 	if err != nil {
-		logger.Errorf("Failed to get protocol client for namespace [%s]: %s", mocks.DefaultNS, err.Error())
+		logger.Errorf("Failed to load operation wait settings: %s", err.Error())
 		panic(err)
 	}
 
-	ctx := sidetreecontext.New(pc)
+	services := make(namespaceServices, 0, len(namespaces))
+
+	for _, ns := range namespaces {
+		svc, e := newNamespaceService(ns, casClient, anchorWriter, batchOpts, waitOpts)
+		if e != nil {
+			logger.Errorf("Failed to create services for namespace [%s]: %s", ns.Namespace, e.Error())
+			panic(e)
+		}
+
+		services = append(services, svc)
+
+		namespace := ns.Namespace
+		svc.opStore.Subscribe(func(ops []*operation.AnchoredOperation) {
+			webhooks.OperationsProcessed(namespace, ops)
+			broker.OperationsProcessed(namespace, ops)
+		})
+	}
+
+	cursor, err := newObserverCursor()
+	if err != nil {
+		logger.Errorf("Failed to create observer cursor: %s", err.Error())
+		panic(err)
+	}
 
-	// create new batch writer
-	batchWriter, err := batch.New(didDocNamespace, ctx)
+	pollInterval, err := getObserverPollInterval()
 	if err != nil {
-		logger.Errorf("Failed to create batch writer: %s", err.Error())
+		logger.Errorf("Failed to load observer settings: %s", err.Error())
 		panic(err)
 	}
 
-	// start routine for creating batches
-	batchWriter.Start()
+	// start routines for creating batches
+	for _, svc := range services {
+		svc.batchWriter.Start()
+	}
 
 	// start observer
-	observer.Start(ctx.Anchor(), pcp)
-
-	// did document handler with did document validator for didDocNamespace
-	didDocHandler := dochandler.New(
-		didDocNamespace,
-		aliases,
-		pc,
-		batchWriter,
-		processor.New(didDocNamespace, opStore, pc),
-		&coremocks.MetricsProvider{},
+	sidetreeObserver := observer.New(anchorWriter, services,
+		observer.WithCursor(cursor),
+		observer.WithPollInterval(pollInterval),
+		observer.WithOperationStoreProvider(&namespaceOpStoreProvider{services: services}),
 	)
+	sidetreeObserver.Subscribe(broker.TransactionProcessed)
+	sidetreeObserver.Start()
 
-	// create discovery rest api
+	// create discovery rest api (discovery points to the first namespace)
 	endpointDiscoveryOp := discoveryrest.New(&discoveryrest.Config{
-		ResolutionPath: resolutionPath,
-		OperationPath:  operationPath,
+		ResolutionPath: namespaces[0].ResolutionPath,
+		OperationPath:  namespaces[0].OperationPath,
 		BaseURL:        config.GetString("external.endpoint"),
 		WellKnownPath:  config.GetString("wellknown.path"),
 	})
 
 	handlers := make([]restcommon.HTTPHandler, 0)
 
+	for _, svc := range services {
+		handlers = append(handlers, svc.restHandlers()...)
+	}
+
 	handlers = append(handlers,
-		diddochandler.NewUpdateHandler(operationPath, didDocHandler, pc, &coremocks.MetricsProvider{}),
-		diddochandler.NewResolveHandler(resolutionPath, &resolveWrapper{coreResolver: didDocHandler}, &coremocks.MetricsProvider{}))
+		unirest.New(&unirest.Config{Resolvers: services.resolvers()}).GetRESTHandlers()...)
 
 	handlers = append(handlers,
 		endpointDiscoveryOp.GetRESTHandlers()...)
 
+	handlers = append(handlers,
+		casrest.New(&casrest.Config{CAS: casClient, Protocols: services.protocolClients()}).GetRESTHandlers()...)
+
+	handlers = append(handlers,
+		eventsrest.New(&eventsrest.Config{Broker: broker}).GetRESTHandlers()...)
+
+	if adminToken := config.GetString("admin.token"); adminToken != "" {
+		adminOp := adminrest.New(&adminrest.Config{
+			Token: adminToken,
+			StateManager: newStateManager(services.operationStores(), casClient, anchorWriter, cursor,
+				services.operationQueues(), services.stateBatchWriters(), sidetreeObserver),
+			Ledger:          anchorWriter,
+			BatchWriters:    services.batchWriters(),
+			OperationStores: services.adminOperationStores(),
+			Aliases:         services.aliases(),
+			Webhooks:        webhooks,
+		})
+
+		handlers = append(handlers, adminOp.GetRESTHandlers()...)
+	} else {
+		logger.Info("admin token is not set - admin API is disabled")
+	}
+
 	restSvc := httpserver.New(
 		getListenURL(),
 		config.GetString("tls.certificate"),
@@ -139,13 +239,274 @@ func main() {
 	<-interrupt
 
 	// Shut down all services
-	batchWriter.Stop()
+	for _, svc := range services {
+		svc.batchWriter.Stop()
+	}
 
 	if err := restSvc.Stop(context.Background()); err != nil {
 		logger.Errorf("Error stopping REST service: %s", err)
 	}
 }
 
+// newOperationStore returns a file-backed operation store if an operation store path is configured,
+// otherwise operations are kept in memory and are lost on restart
+func newOperationStore(path string) (*mocks.MockOperationStore, error) {
+	if path == "" {
+		return mocks.NewMockOperationStore(), nil
+	}
+
+	logger.Infof("using operation store at [%s]", path)
+
+	return mocks.NewFileOperationStore(path)
+}
+
+// newOperationQueue returns a file-backed operation queue if an operation queue path is configured,
+// otherwise queued operations that weren't anchored are lost on restart
+func newOperationQueue(path string) (*opqueue.Queue, error) {
+	if path == "" {
+		return opqueue.New(), nil
+	}
+
+	logger.Infof("using operation queue at [%s]", path)
+
+	return opqueue.NewFileQueue(path)
+}
+
+// newCasClient returns a file-backed CAS client if a CAS path is configured,
+// otherwise batch files are kept in memory and are lost on restart
+func newCasClient() (*mocks.MockCasClient, error) {
+	path := config.GetString("cas.path")
+	if path == "" {
+		return mocks.NewMockCasClient(nil), nil
+	}
+
+	logger.Infof("using CAS at [%s]", path)
+
+	return mocks.NewFileCasClient(path)
+}
+
+// newAnchorWriter returns a file-backed ledger if a ledger path is configured,
+// otherwise anchored transactions are kept in memory and are lost on restart
+func newAnchorWriter(namespace string) (*observer.AnchorWriter, error) {
+	blockTime := config.GetDuration("ledger.block.time")
+
+	opts := []observer.AnchorWriterOption{observer.WithBlockTime(blockTime)}
+
+	switch mode := config.GetString("ledger.mode"); mode {
+	case "", ledgerModeAuto:
+	case ledgerModeSync:
+		if blockTime != 0 {
+			return nil, fmt.Errorf("ledger block time [%s] is not supported in [%s] mode", blockTime, ledgerModeSync)
+		}
+
+		logger.Info("ledger is in sync mode - anchors are processed by the observer as soon as they are written")
+
+		opts = append(opts, observer.WithSynchronousDelivery())
+	case ledgerModeManual:
+		logger.Info("ledger is in manual mining mode - anchors are visible only after blocks are mined")
+
+		opts = append(opts, observer.WithManualMining())
+	default:
+		return nil, fmt.Errorf("invalid ledger mode [%s] - supported modes are [%s], [%s] and [%s]",
+			mode, ledgerModeAuto, ledgerModeSync, ledgerModeManual)
+	}
+
+	path := config.GetString("ledger.path")
+	if path == "" {
+		return observer.NewAnchorWriter(namespace, opts...), nil
+	}
+
+	logger.Infof("using ledger at [%s]", path)
+
+	return observer.NewFileAnchorWriter(namespace, path, opts...)
+}
+
+// getProtocols returns the protocol versions defined in the YAML or JSON file referenced by SIDETREE_MOCK_PROTOCOL_FILE
+// or in SIDETREE_MOCK_PROTOCOL_VERSIONS (a JSON array of protocol versions). Every protocol version must list every
+// parameter since nothing is inherited from the previous version. The default protocol is returned if neither is set.
+func getProtocols() ([]protocol.Protocol, error) {
+	file := config.GetString("protocol.file")
+	versions := config.GetString("protocol.versions")
+
+	var (
+		protocols []protocol.Protocol
+		err       error
+	)
+
+	switch {
+	case file != "" && versions != "":
+		return nil, fmt.Errorf("protocol file and protocol versions must not both be set")
+	case file != "":
+		protocols, err = protocolconfig.Load(file)
+	case versions != "":
+		protocols, err = protocolconfig.Parse([]byte(versions))
+	default:
+		return []protocol.Protocol{mocks.DefaultProtocol()}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range protocols {
+		logger.Infof("protocol version with genesis time %d: %+v", p.GenesisTime, p)
+	}
+
+	return protocols, nil
+}
+
+// getBatchOptions returns the batch policy. A batch is cut as soon as it holds SIDETREE_MOCK_BATCH_MAX_OPERATIONS
+// operations or SIDETREE_MOCK_BATCH_MAX_BYTES bytes of operation requests, or when its oldest operation has been
+// queued for SIDETREE_MOCK_BATCH_MAX_AGE. The maximum number of operations and bytes are limited by the protocol.
+// By default batches are cut as soon as operations are queued.
+func getBatchOptions() ([]batchwriter.Option, error) {
+	maxOperations := config.GetInt("batch.max.operations")
+	if maxOperations < 0 {
+		return nil, fmt.Errorf("batch max operations must not be negative")
+	}
+
+	maxBytes := config.GetInt("batch.max.bytes")
+	if maxBytes < 0 {
+		return nil, fmt.Errorf("batch max bytes must not be negative")
+	}
+
+	maxAge := config.GetDuration("batch.max.age")
+	if maxAge < 0 {
+		return nil, fmt.Errorf("batch max age must not be negative")
+	}
+
+	logger.Infof("batch policy: max operations [%d], max bytes [%d], max age [%s]", maxOperations, maxBytes, maxAge)
+
+	return []batchwriter.Option{
+		batchwriter.WithMaxOperations(uint(maxOperations)),
+		batchwriter.WithMaxBytes(maxBytes),
+		batchwriter.WithMaxAge(maxAge),
+	}, nil
+}
+
+// getWebhookOptions returns the webhook delivery settings. A failed delivery is retried up to
+// SIDETREE_MOCK_WEBHOOK_MAX_ATTEMPTS times in total, waiting SIDETREE_MOCK_WEBHOOK_RETRY_INTERVAL before the first
+// retry and doubling the wait for every further retry. SIDETREE_MOCK_WEBHOOK_TIMEOUT is the timeout of a delivery.
+func getWebhookOptions() ([]webhook.Option, error) {
+	var opts []webhook.Option
+
+	if config.GetString("webhook.max.attempts") != "" {
+		maxAttempts := config.GetInt("webhook.max.attempts")
+		if maxAttempts < 1 {
+			return nil, fmt.Errorf("webhook max attempts must be greater than zero")
+		}
+
+		opts = append(opts, webhook.WithMaxAttempts(maxAttempts))
+	}
+
+	if config.GetString("webhook.retry.interval") != "" {
+		interval := config.GetDuration("webhook.retry.interval")
+		if interval <= 0 {
+			return nil, fmt.Errorf("webhook retry interval must be greater than zero")
+		}
+
+		opts = append(opts, webhook.WithRetryInterval(interval))
+	}
+
+	if config.GetString("webhook.timeout") != "" {
+		timeout := config.GetDuration("webhook.timeout")
+		if timeout <= 0 {
+			return nil, fmt.Errorf("webhook timeout must be greater than zero")
+		}
+
+		opts = append(opts, webhook.WithTimeout(timeout))
+	}
+
+	return opts, nil
+}
+
+// getWaitOptions returns the settings for requests that wait for their operation to become visible through
+// resolution. SIDETREE_MOCK_OPERATION_WAIT_TIMEOUT is the default and maximum time to wait.
+func getWaitOptions() ([]wait.Option, error) {
+	var opts []wait.Option
+
+	if config.GetString("operation.wait.timeout") != "" {
+		timeout := config.GetDuration("operation.wait.timeout")
+		if timeout <= 0 {
+			return nil, fmt.Errorf("operation wait timeout must be greater than zero")
+		}
+
+		opts = append(opts, wait.WithTimeout(timeout))
+	}
+
+	return opts, nil
+}
+
+// getEventOptions returns the event stream settings. SIDETREE_MOCK_EVENTS_HISTORY_SIZE is the number of recent
+// events that are retained for clients that resume the stream with the Last-Event-ID header.
+func getEventOptions() ([]events.Option, error) {
+	var opts []events.Option
+
+	if config.GetString("events.history.size") != "" {
+		historySize := config.GetInt("events.history.size")
+		if historySize < 0 {
+			return nil, fmt.Errorf("events history size must not be negative")
+		}
+
+		opts = append(opts, events.WithHistorySize(historySize))
+	}
+
+	return opts, nil
+}
+
+// newObserverCursor returns a file-backed observer cursor if a cursor path is configured,
+// otherwise the observer reads the ledger from the first transaction on every start
+func newObserverCursor() (*observer.Cursor, error) {
+	path := config.GetString("observer.cursor.path")
+	if path == "" {
+		return observer.NewCursor(), nil
+	}
+
+	logger.Infof("using observer cursor at [%s]", path)
+
+	return observer.NewFileCursor(path)
+}
+
+func newStateManager(opStores map[string]state.OperationStore, casClient *mocks.MockCasClient,
+	anchorWriter *observer.AnchorWriter, cursor *observer.Cursor, opQueues []state.OperationQueue,
+	batchWriters []state.BatchWriter, o state.Observer) *state.Manager {
+	return state.New(&state.Providers{
+		OperationStores: opStores,
+		CAS:             casClient,
+		Ledger:          anchorWriter,
+		Cursor:          cursor,
+		OperationQueues: opQueues,
+		BatchWriters:    batchWriters,
+		Observer:        o,
+	})
+}
+
+func getDIDDocNamespace() string {
+	if config.GetString("did.namespace") != "" {
+		return config.GetString("did.namespace")
+	}
+
+	return defaultDIDDocNamespace
+}
+
+func getObserverPollInterval() (time.Duration, error) {
+	value := config.GetString("observer.poll.interval")
+	if value == "" {
+		return defaultObserverPollInterval, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid observer poll interval [%s]: %w", value, err)
+	}
+
+	if interval <= 0 {
+		return 0, fmt.Errorf("observer poll interval [%s] must be greater than zero", value)
+	}
+
+	return interval, nil
+}
+
 func getListenURL() string {
 	host := config.GetString("host")
 	if host == "" {
@@ -163,7 +524,48 @@ type resolveWrapper struct {
 }
 
 func (rw *resolveWrapper) ResolveDocument(id string, opts ...document.ResolutionOption) (*document.ResolutionResult, error) {
-	return rw.coreResolver.ResolveDocument(id, opts...)
+	result, err := rw.coreResolver.ResolveDocument(id, opts...)
+	if err != nil {
+		return nil, versionError(err)
+	}
+
+	return result, nil
+}
+
+// versionError maps the errors returned when the requested version of a document (versionId or versionTime)
+// doesn't exist or is invalid to errors that the resolve handler reports as 404 and 400 respectively (instead of
+// 500).
+func versionError(err error) error {
+	switch {
+	case errors.Is(err, history.ErrInvalidVersionTime), errors.Is(err, history.ErrInvalidVersion):
+		return fmt.Errorf("bad request: %w", err)
+	case errors.Is(err, history.ErrVersionNotFound):
+		return fmt.Errorf("version not found: %w", err)
+	default:
+		return err
+	}
+}
+
+// representationHandler serves the resolution results of the wrapped resolve handler in the representation that is
+// requested by the Accept header (resolution result, application/did+json, application/did+ld+json or
+// application/did+cbor)
+type representationHandler struct {
+	restcommon.HTTPHandler
+}
+
+func (h *representationHandler) Handler() restcommon.HTTPRequestHandler {
+	return representation.NewHandler(h.HTTPHandler.Handler())
+}
+
+// waitHandler holds back the response of the wrapped operation handler until the operation is visible through
+// resolution if the request asks to wait
+type waitHandler struct {
+	restcommon.HTTPHandler
+	waiter *wait.Waiter
+}
+
+func (h *waitHandler) Handler() restcommon.HTTPRequestHandler {
+	return h.waiter.NewHandler(h.HTTPHandler.Handler())
 }
 
 type coreResolver interface {
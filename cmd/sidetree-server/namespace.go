@@ -0,0 +1,306 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/dochandler"
+	coremocks "github.com/trustbloc/sidetree-core-go/pkg/mocks"
+	coreobserver "github.com/trustbloc/sidetree-core-go/pkg/observer"
+	restcommon "github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/diddochandler"
+
+	adminrest "github.com/trustbloc/sidetree-mock/pkg/admin/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/batchwriter"
+	casrest "github.com/trustbloc/sidetree-mock/pkg/cas/endpoint/restapi"
+	sidetreecontext "github.com/trustbloc/sidetree-mock/pkg/context"
+	"github.com/trustbloc/sidetree-mock/pkg/history"
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+	"github.com/trustbloc/sidetree-mock/pkg/namespaceconfig"
+	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	"github.com/trustbloc/sidetree-mock/pkg/state"
+	unirest "github.com/trustbloc/sidetree-mock/pkg/uniresolver/endpoint/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/wait"
+)
+
+// namespaceService holds the components that serve a DID namespace. All namespaces share the ledger
+// and the CAS but each namespace has its own operation store, protocol versions and batch writer.
+type namespaceService struct {
+	config      *namespaceconfig.Namespace
+	opStore     *mocks.MockOperationStore
+	pc          protocol.Client
+	ctx         *sidetreecontext.ServerContext
+	batchWriter *batchwriter.Writer
+	docHandler  *dochandler.DocumentHandler
+	waiter      *wait.Waiter
+}
+
+func newNamespaceService(ns *namespaceconfig.Namespace, casClient *mocks.MockCasClient,
+	anchorWriter *observer.AnchorWriter, batchOpts []batchwriter.Option,
+	waitOpts []wait.Option) (*namespaceService, error) {
+	opStore, err := newOperationStore(ns.OperationStorePath)
+	if err != nil {
+		return nil, fmt.Errorf("create operation store: %w", err)
+	}
+
+	// the current protocol version is selected based on the height of the ledger
+	pc, err := mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithOpStoreClient(opStore).WithCasClient(casClient).
+		WithMethodContext(ns.MethodContext).WithBase(ns.BaseEnabled).WithProtocols(ns.Protocols...).
+		WithLedgerTime(anchorWriter.Height).ForNamespace(ns.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	opQueue, err := newOperationQueue(ns.OperationQueuePath)
+	if err != nil {
+		return nil, fmt.Errorf("create operation queue: %w", err)
+	}
+
+	ctx := sidetreecontext.New(pc, anchorWriter.ForNamespace(ns.Namespace), opQueue)
+
+	batchWriter, err := batchwriter.New(ns.Namespace, ctx, batchOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create batch writer: %w", err)
+	}
+
+	docHandler := dochandler.New(
+		ns.Namespace,
+		ns.Aliases,
+		pc,
+		batchWriter,
+		history.New(ns.Namespace, opStore, pc, anchorWriter),
+		&coremocks.MetricsProvider{},
+	)
+
+	return &namespaceService{
+		config:      ns,
+		opStore:     opStore,
+		pc:          pc,
+		ctx:         ctx,
+		batchWriter: batchWriter,
+		docHandler:  docHandler,
+		waiter:      wait.New(ns.Namespace, pc, opStore, anchorWriter, docHandler, waitOpts...),
+	}, nil
+}
+
+// restHandlers returns the operation and resolution handlers of the namespace
+func (s *namespaceService) restHandlers() []restcommon.HTTPHandler {
+	return []restcommon.HTTPHandler{
+		&waitHandler{
+			HTTPHandler: diddochandler.NewUpdateHandler(s.config.OperationPath, s.docHandler, s.pc,
+				&coremocks.MetricsProvider{}),
+			waiter: s.waiter,
+		},
+		&representationHandler{
+			HTTPHandler: diddochandler.NewResolveHandler(s.config.ResolutionPath,
+				&resolveWrapper{coreResolver: s.docHandler}, &coremocks.MetricsProvider{}),
+		},
+	}
+}
+
+// namespaceServices holds the services of all namespaces hosted by the node
+type namespaceServices []*namespaceService
+
+// ForNamespace returns the protocol client of the given namespace (implements protocol.ClientProvider)
+func (s namespaceServices) ForNamespace(namespace string) (protocol.Client, error) {
+	ns, err := s.get(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.pc, nil
+}
+
+// operationStores returns the operation store of each namespace
+func (s namespaceServices) operationStores() map[string]state.OperationStore {
+	opStores := make(map[string]state.OperationStore, len(s))
+	for _, ns := range s {
+		opStores[ns.config.Namespace] = ns.opStore
+	}
+
+	return opStores
+}
+
+// adminOperationStores returns the operation store of each namespace for the admin API
+func (s namespaceServices) adminOperationStores() map[string]adminrest.OperationStore {
+	opStores := make(map[string]adminrest.OperationStore, len(s))
+	for _, ns := range s {
+		opStores[ns.config.Namespace] = ns.opStore
+	}
+
+	return opStores
+}
+
+// aliases maps the namespace aliases to their namespaces
+func (s namespaceServices) aliases() map[string]string {
+	aliases := make(map[string]string)
+	for _, ns := range s {
+		for _, alias := range ns.config.Aliases {
+			aliases[alias] = ns.config.Namespace
+		}
+	}
+
+	return aliases
+}
+
+// resolvers returns the resolver of each namespace and namespace alias for the Universal Resolver driver API
+func (s namespaceServices) resolvers() map[string]unirest.Resolver {
+	resolvers := make(map[string]unirest.Resolver)
+	for _, ns := range s {
+		resolver := &resolveWrapper{coreResolver: ns.docHandler}
+
+		resolvers[ns.config.Namespace] = resolver
+		for _, alias := range ns.config.Aliases {
+			resolvers[alias] = resolver
+		}
+	}
+
+	return resolvers
+}
+
+// operationQueues returns the operation queue of each namespace
+func (s namespaceServices) operationQueues() []state.OperationQueue {
+	opQueues := make([]state.OperationQueue, 0, len(s))
+	for _, ns := range s {
+		opQueues = append(opQueues, ns.ctx.OpQueue)
+	}
+
+	return opQueues
+}
+
+// protocolClients returns the protocol client of each namespace for the CAS API
+func (s namespaceServices) protocolClients() []casrest.ProtocolClient {
+	protocolClients := make([]casrest.ProtocolClient, 0, len(s))
+	for _, ns := range s {
+		protocolClients = append(protocolClients, ns.pc)
+	}
+
+	return protocolClients
+}
+
+// batchWriters returns the batch writer of each namespace
+func (s namespaceServices) batchWriters() []adminrest.BatchWriter {
+	batchWriters := make([]adminrest.BatchWriter, 0, len(s))
+	for _, ns := range s {
+		batchWriters = append(batchWriters, ns.batchWriter)
+	}
+
+	return batchWriters
+}
+
+// stateBatchWriters returns the batch writer of each namespace for the state manager
+func (s namespaceServices) stateBatchWriters() []state.BatchWriter {
+	batchWriters := make([]state.BatchWriter, 0, len(s))
+	for _, ns := range s {
+		batchWriters = append(batchWriters, ns.batchWriter)
+	}
+
+	return batchWriters
+}
+
+func (s namespaceServices) get(namespace string) (*namespaceService, error) {
+	for _, ns := range s {
+		if ns.config.Namespace == namespace {
+			return ns, nil
+		}
+	}
+
+	return nil, fmt.Errorf("namespace [%s] is not hosted by this node", namespace)
+}
+
+// namespaceOpStoreProvider provides the operation store of each namespace to the observer
+type namespaceOpStoreProvider struct {
+	services namespaceServices
+}
+
+// ForNamespace returns the operation store of the given namespace
+func (p *namespaceOpStoreProvider) ForNamespace(namespace string) (coreobserver.OperationStore, error) {
+	ns, err := p.services.get(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.opStore, nil
+}
+
+// getNamespaces returns the namespaces defined in the YAML or JSON file referenced by SIDETREE_MOCK_NAMESPACES_FILE
+// or in SIDETREE_MOCK_NAMESPACES (a JSON array). If neither is set then a single namespace is configured from
+// SIDETREE_MOCK_DID_NAMESPACE, SIDETREE_MOCK_DID_ALIASES and the protocol settings.
+//
+// The method context, @base, protocol, operation store and operation queue settings serve as defaults for the
+// namespaces in the list. If an operation store or operation queue path is configured then each namespace in
+// the list gets its own operation store or queue at that path suffixed with the namespace.
+func getNamespaces() ([]*namespaceconfig.Namespace, error) {
+	protocols, err := getProtocols()
+	if err != nil {
+		return nil, fmt.Errorf("load protocol versions: %w", err)
+	}
+
+	defaults := &namespaceconfig.Defaults{
+		MethodContext:      getMethodContext(),
+		BaseEnabled:        config.GetBool("did.base.enabled"),
+		Protocols:          protocols,
+		OperationStorePath: config.GetString("opstore.path"),
+		OperationQueuePath: config.GetString("opqueue.path"),
+	}
+
+	file := config.GetString("namespaces.file")
+	list := config.GetString("namespaces")
+
+	var namespaces []*namespaceconfig.Namespace
+
+	switch {
+	case file != "" && list != "":
+		return nil, fmt.Errorf("namespaces file and namespaces must not both be set")
+	case file != "":
+		namespaces, err = namespaceconfig.Load(file, defaults)
+	case list != "":
+		namespaces, err = namespaceconfig.Parse([]byte(list), defaults)
+	default:
+		namespaces = []*namespaceconfig.Namespace{{
+			Namespace:          getDIDDocNamespace(),
+			Aliases:            getAliases(),
+			OperationPath:      operationPath,
+			ResolutionPath:     resolutionPath,
+			MethodContext:      defaults.MethodContext,
+			BaseEnabled:        defaults.BaseEnabled,
+			Protocols:          defaults.Protocols,
+			OperationStorePath: defaults.OperationStorePath,
+			OperationQueuePath: defaults.OperationQueuePath,
+		}}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ns := range namespaces {
+		logger.Infof("namespace [%s] with aliases %v: operations at [%s], resolution at [%s]",
+			ns.Namespace, ns.Aliases, ns.OperationPath, ns.ResolutionPath)
+	}
+
+	return namespaces, nil
+}
+
+func getAliases() []string {
+	if config.GetString("did.aliases") == "" {
+		return nil
+	}
+
+	return strings.Split(config.GetString("did.aliases"), arrayDelimiter)
+}
+
+func getMethodContext() []string {
+	if config.GetString("did.method.context") == "" {
+		return nil
+	}
+
+	return strings.Split(config.GetString("did.method.context"), arrayDelimiter)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+
+	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	"github.com/trustbloc/sidetree-mock/pkg/webhook"
+)
+
+// ErrorResponse to send error message in the response.
+type ErrorResponse struct {
+	Message string `json:"errMessage,omitempty"`
+}
+
+// MineRequest is the request to mine blocks.
+type MineRequest struct {
+	Count int `json:"count,omitempty"`
+}
+
+// MineResponse contains the transactions that were included in the mined blocks.
+type MineResponse struct {
+	BlockHeight  uint64             `json:"blockHeight"`
+	Transactions []*txn.SidetreeTxn `json:"transactions"`
+}
+
+// ReorgRequest is the request to orphan the last Count transactions of the ledger. The replacement
+// anchors (if any) are included in a new block.
+type ReorgRequest struct {
+	Count        int                `json:"count,omitempty"`
+	Replacements []*observer.Anchor `json:"replacements,omitempty"`
+}
+
+// ReorgResponse contains the orphaned transactions and the transactions that replaced them.
+type ReorgResponse struct {
+	Orphaned     []*txn.SidetreeTxn `json:"orphaned"`
+	Replacements []*txn.SidetreeTxn `json:"replacements"`
+}
+
+// QueuedOperation is an operation that is waiting to be batched.
+type QueuedOperation struct {
+	// ID identifies the operation in the queue of its namespace
+	ID              uint64         `json:"id"`
+	Namespace       string         `json:"namespace"`
+	Type            operation.Type `json:"type,omitempty"`
+	UniqueSuffix    string         `json:"uniqueSuffix"`
+	Size            int            `json:"size"`
+	ProtocolVersion uint64         `json:"protocolVersion"`
+	QueuedTime      time.Time      `json:"queuedTime"`
+}
+
+// QueueResponse contains the operations that are waiting to be batched.
+type QueueResponse struct {
+	Operations []*QueuedOperation `json:"operations"`
+}
+
+// CutRequest is the request to cut a batch. The pending operations of all namespaces are cut if no namespace is given.
+type CutRequest struct {
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CutResponse contains the number of operations that were anchored for each namespace.
+type CutResponse struct {
+	Anchored map[string]int `json:"anchored"`
+}
+
+// DID is a DID that has anchored operations.
+type DID struct {
+	ID           string `json:"id"`
+	Namespace    string `json:"namespace"`
+	UniqueSuffix string `json:"uniqueSuffix"`
+	// Operations is the number of anchored operations
+	Operations int `json:"operations"`
+	// CreatedTime is the ledger time of the transaction that anchored the create operation
+	CreatedTime              *uint64        `json:"createdTime,omitempty"`
+	CreatedTransactionNumber *uint64        `json:"createdTransactionNumber,omitempty"`
+	LastOperationType        operation.Type `json:"lastOperationType"`
+	// Deactivated is true if a deactivate operation was anchored for the DID
+	Deactivated bool `json:"deactivated"`
+}
+
+// DIDsResponse contains a page of DIDs. Next is set to the last DID of the page if there are more DIDs.
+type DIDsResponse struct {
+	DIDs []*DID `json:"dids"`
+	Next string `json:"next,omitempty"`
+}
+
+// AnchoredOperation is an operation that was anchored in the ledger. Request is the operation request as
+// submitted by the client.
+type AnchoredOperation struct {
+	Type                 operation.Type  `json:"type"`
+	UniqueSuffix         string          `json:"uniqueSuffix"`
+	TransactionTime      uint64          `json:"transactionTime"`
+	TransactionNumber    uint64          `json:"transactionNumber"`
+	ProtocolVersion      uint64          `json:"protocolVersion"`
+	CanonicalReference   string          `json:"canonicalReference,omitempty"`
+	EquivalentReferences []string        `json:"equivalentReferences,omitempty"`
+	AnchorOrigin         interface{}     `json:"anchorOrigin,omitempty"`
+	Request              json.RawMessage `json:"request"`
+}
+
+// DIDOperationsResponse contains the anchored operations of a DID ordered by transaction.
+type DIDOperationsResponse struct {
+	ID         string               `json:"id"`
+	Operations []*AnchoredOperation `json:"operations"`
+}
+
+// TransactionsResponse contains a page of ledger transactions. Next is set to the number of the last transaction
+// of the page if there are more transactions.
+type TransactionsResponse struct {
+	Transactions []*txn.SidetreeTxn `json:"transactions"`
+	Next         *uint64            `json:"next,omitempty"`
+}
+
+// TransactionResponse contains a ledger transaction along with the data of its anchor string. CoreIndexFile and
+// DecodedCoreIndexFile are the paths at which the core index file is served by the CAS REST API.
+type TransactionResponse struct {
+	Transaction          *txn.SidetreeTxn `json:"transaction"`
+	NumberOfOperations   int              `json:"numberOfOperations,omitempty"`
+	CoreIndexFileURI     string           `json:"coreIndexFileUri,omitempty"`
+	CoreIndexFile        string           `json:"coreIndexFile,omitempty"`
+	DecodedCoreIndexFile string           `json:"decodedCoreIndexFile,omitempty"`
+}
+
+// WebhooksResponse contains the webhook subscriptions.
+type WebhooksResponse struct {
+	Webhooks []*webhook.Subscription `json:"webhooks"`
+}
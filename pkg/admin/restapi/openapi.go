@@ -0,0 +1,245 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"github.com/trustbloc/sidetree-mock/pkg/webhook"
+)
+
+// genericError model
+//
+// swagger:response genericError
+type genericError struct { // nolint: unused,deadcode
+	// in: body
+	Body ErrorResponse
+}
+
+// emptyResp model
+//
+// swagger:response emptyResp
+type emptyResp struct{} // nolint: unused,deadcode
+
+// snapshotReq model
+//
+// swagger:parameters snapshotReq
+type snapshotReq struct{} // nolint: unused,deadcode
+
+// snapshotResp model
+//
+// swagger:response snapshotResp
+type snapshotResp struct { // nolint: unused,deadcode
+	// in: body
+	Body []byte
+}
+
+// restoreReq model
+//
+// swagger:parameters restoreReq
+type restoreReq struct { // nolint: unused,deadcode
+	// in: body
+	Body []byte
+}
+
+// resetReq model
+//
+// swagger:parameters resetReq
+type resetReq struct{} // nolint: unused,deadcode
+
+// mineReq model
+//
+// swagger:parameters mineReq
+type mineReq struct { // nolint: unused,deadcode
+	// in: body
+	Body MineRequest
+}
+
+// mineResp model
+//
+// swagger:response mineResp
+type mineResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *MineResponse
+}
+
+// reorgReq model
+//
+// swagger:parameters reorgReq
+type reorgReq struct { // nolint: unused,deadcode
+	// in: body
+	Body ReorgRequest
+}
+
+// reorgResp model
+//
+// swagger:response reorgResp
+type reorgResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *ReorgResponse
+}
+
+// queueReq model
+//
+// swagger:parameters queueReq
+type queueReq struct { // nolint: unused,deadcode
+	// in: query
+	Namespace string `json:"namespace"`
+}
+
+// queueResp model
+//
+// swagger:response queueResp
+type queueResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *QueueResponse
+}
+
+// removeQueuedReq model
+//
+// swagger:parameters removeQueuedReq
+type removeQueuedReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	Namespace string `json:"namespace"`
+
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+}
+
+// cutReq model
+//
+// swagger:parameters cutReq
+type cutReq struct { // nolint: unused,deadcode
+	// in: body
+	Body CutRequest
+}
+
+// cutResp model
+//
+// swagger:response cutResp
+type cutResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *CutResponse
+}
+
+// didsReq model
+//
+// swagger:parameters didsReq
+type didsReq struct { // nolint: unused,deadcode
+	// in: query
+	Namespace string `json:"namespace"`
+
+	// in: query
+	From uint64 `json:"from"`
+
+	// in: query
+	To uint64 `json:"to"`
+
+	// in: query
+	After string `json:"after"`
+
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// didsResp model
+//
+// swagger:response didsResp
+type didsResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *DIDsResponse
+}
+
+// didOperationsReq model
+//
+// swagger:parameters didOperationsReq
+type didOperationsReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	DID string `json:"did"`
+}
+
+// didOperationsResp model
+//
+// swagger:response didOperationsResp
+type didOperationsResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *DIDOperationsResponse
+}
+
+// transactionsReq model
+//
+// swagger:parameters transactionsReq
+type transactionsReq struct { // nolint: unused,deadcode
+	// in: query
+	Since uint64 `json:"since"`
+
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// transactionsResp model
+//
+// swagger:response transactionsResp
+type transactionsResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *TransactionsResponse
+}
+
+// transactionReq model
+//
+// swagger:parameters transactionReq
+type transactionReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	Number uint64 `json:"number"`
+}
+
+// transactionResp model
+//
+// swagger:response transactionResp
+type transactionResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *TransactionResponse
+}
+
+// webhooksReq model
+//
+// swagger:parameters webhooksReq
+type webhooksReq struct{} // nolint: unused,deadcode
+
+// webhooksResp model
+//
+// swagger:response webhooksResp
+type webhooksResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *WebhooksResponse
+}
+
+// subscribeReq model
+//
+// swagger:parameters subscribeReq
+type subscribeReq struct { // nolint: unused,deadcode
+	// in: body
+	Body webhook.Subscription
+}
+
+// subscribeResp model
+//
+// swagger:response subscribeResp
+type subscribeResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *webhook.Subscription
+}
+
+// unsubscribeReq model
+//
+// swagger:parameters unsubscribeReq
+type unsubscribeReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
@@ -0,0 +1,931 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/txnprovider"
+
+	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	"github.com/trustbloc/sidetree-mock/pkg/opinfo"
+	"github.com/trustbloc/sidetree-mock/pkg/opqueue"
+	"github.com/trustbloc/sidetree-mock/pkg/webhook"
+)
+
+var logger = log.New("admin-rest")
+
+// errNamespaceNotFound is returned if a DID isn't in any of the namespaces (or aliases) of the node
+var errNamespaceNotFound = errors.New("namespace not found")
+
+// API endpoints.
+const (
+	snapshotEndpoint = "/admin/snapshot"
+	restoreEndpoint  = "/admin/restore"
+	resetEndpoint    = "/admin/reset"
+	blocksEndpoint   = "/admin/ledger/blocks"
+	reorgEndpoint    = "/admin/ledger/reorg"
+	queueEndpoint    = "/admin/queue"
+	queueOpEndpoint  = "/admin/queue/{namespace}/{id}"
+	cutEndpoint      = "/admin/queue/cut"
+	didsEndpoint     = "/admin/dids"
+	didOpsEndpoint   = "/admin/dids/{did}/operations"
+	txnsEndpoint     = "/admin/ledger/transactions"
+	txnEndpoint      = "/admin/ledger/transactions/{number}"
+	webhooksEndpoint = "/admin/webhooks"
+	webhookEndpoint  = "/admin/webhooks/{id}"
+)
+
+// casContentPath is the path at which CAS content is served (see package cas/endpoint/restapi)
+const casContentPath = "/cas/"
+
+const (
+	namespaceParam = "namespace"
+	idParam        = "id"
+	didParam       = "did"
+	numberParam    = "number"
+	sinceParam     = "since"
+	fromParam      = "from"
+	toParam        = "to"
+	afterParam     = "after"
+	limitParam     = "limit"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+const archiveContentType = "application/gzip"
+
+type stateManager interface {
+	Export(w io.Writer) error
+	Import(r io.Reader) error
+	Reset() error
+}
+
+type ledger interface {
+	Mine(blocks int) ([]*txn.SidetreeTxn, error)
+	Height() uint64
+	Reorg(count int, replacements []*observer.Anchor) ([]*txn.SidetreeTxn, []*txn.SidetreeTxn, error)
+	Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn)
+}
+
+type webhooks interface {
+	Subscribe(s *webhook.Subscription) (*webhook.Subscription, error)
+	Unsubscribe(id string) bool
+	Subscriptions() []*webhook.Subscription
+}
+
+// BatchWriter cuts the pending operations of a namespace into batches
+type BatchWriter interface {
+	Namespace() string
+	Pending() []*opqueue.Entry
+	RemovePending(id uint64) (bool, error)
+	Cut() (int, error)
+}
+
+// OperationStore holds the anchored operations of a namespace
+type OperationStore interface {
+	Suffixes() []string
+	Get(suffix string) ([]*operation.AnchoredOperation, error)
+}
+
+// New returns admin operations.
+func New(c *Config) *Operation {
+	return &Operation{
+		token:        c.Token,
+		stateManager: c.StateManager,
+		ledger:       c.Ledger,
+		batchWriters: c.BatchWriters,
+		opStores:     c.OperationStores,
+		aliases:      c.Aliases,
+		webhooks:     c.Webhooks,
+	}
+}
+
+// Operation defines handlers for admin operations.
+type Operation struct {
+	token        string
+	stateManager stateManager
+	ledger       ledger
+	batchWriters []BatchWriter
+	opStores     map[string]OperationStore
+	aliases      map[string]string
+	webhooks     webhooks
+}
+
+// Config defines configuration for admin operations.
+type Config struct {
+	// Token is the bearer token that must be provided in order to invoke admin operations
+	Token        string
+	StateManager stateManager
+	Ledger       ledger
+	// BatchWriters are the batch writers of the namespaces hosted by the node
+	BatchWriters []BatchWriter
+	// OperationStores are the operation stores of the namespaces hosted by the node
+	OperationStores map[string]OperationStore
+	// Aliases maps the namespace aliases of the node to their namespaces
+	Aliases map[string]string
+	// Webhooks holds the webhook subscriptions
+	Webhooks webhooks
+}
+
+// GetRESTHandlers get all controller API handler available for this service.
+func (o *Operation) GetRESTHandlers() []common.HTTPHandler {
+	return []common.HTTPHandler{
+		o.newHTTPHandler(snapshotEndpoint, http.MethodGet, o.snapshotHandler),
+		o.newHTTPHandler(restoreEndpoint, http.MethodPost, o.restoreHandler),
+		o.newHTTPHandler(resetEndpoint, http.MethodPost, o.resetHandler),
+		o.newHTTPHandler(blocksEndpoint, http.MethodPost, o.mineHandler),
+		o.newHTTPHandler(reorgEndpoint, http.MethodPost, o.reorgHandler),
+		o.newHTTPHandler(queueEndpoint, http.MethodGet, o.queueHandler),
+		o.newHTTPHandler(queueOpEndpoint, http.MethodDelete, o.removeQueuedHandler),
+		o.newHTTPHandler(cutEndpoint, http.MethodPost, o.cutHandler),
+		o.newHTTPHandler(didsEndpoint, http.MethodGet, o.didsHandler),
+		o.newHTTPHandler(didOpsEndpoint, http.MethodGet, o.didOperationsHandler),
+		o.newHTTPHandler(txnsEndpoint, http.MethodGet, o.transactionsHandler),
+		o.newHTTPHandler(txnEndpoint, http.MethodGet, o.transactionHandler),
+		o.newHTTPHandler(webhooksEndpoint, http.MethodGet, o.webhooksHandler),
+		o.newHTTPHandler(webhooksEndpoint, http.MethodPost, o.subscribeHandler),
+		o.newHTTPHandler(webhookEndpoint, http.MethodDelete, o.unsubscribeHandler),
+	}
+}
+
+// snapshotHandler swagger:route Get /admin/snapshot admin snapshotReq
+//
+// snapshotHandler exports the full node state as a gzipped tar archive.
+//
+// Responses:
+//    default: genericError
+//        200: snapshotResp
+func (o *Operation) snapshotHandler(rw http.ResponseWriter, _ *http.Request) {
+	archive := &bytes.Buffer{}
+
+	if err := o.stateManager.Export(archive); err != nil {
+		writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("export state: %s", err))
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", archiveContentType)
+	rw.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"sidetree-snapshot-%s.tar.gz\"", time.Now().UTC().Format("20060102T150405Z")))
+	rw.WriteHeader(http.StatusOK)
+
+	if _, err := rw.Write(archive.Bytes()); err != nil {
+		logger.Errorf("Unable to send snapshot: %s", err)
+	}
+}
+
+// restoreHandler swagger:route Post /admin/restore admin restoreReq
+//
+// restoreHandler replaces the full node state with the state in the provided archive.
+//
+// Responses:
+//    default: genericError
+//        200: emptyResp
+func (o *Operation) restoreHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := o.stateManager.Import(r.Body); err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("import state: %s", err))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// resetHandler swagger:route Post /admin/reset admin resetReq
+//
+// resetHandler removes all state from the node (operation store, CAS, operation queue, ledger and observer cursor).
+//
+// Responses:
+//    default: genericError
+//        200: emptyResp
+func (o *Operation) resetHandler(rw http.ResponseWriter, _ *http.Request) {
+	if err := o.stateManager.Reset(); err != nil {
+		writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("reset state: %s", err))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// mineHandler swagger:route Post /admin/ledger/blocks admin mineReq
+//
+// mineHandler mines the requested number of blocks (one by default). The first block includes
+// all pending anchors and the remaining blocks are empty.
+//
+// Responses:
+//    default: genericError
+//        200: mineResp
+func (o *Operation) mineHandler(rw http.ResponseWriter, r *http.Request) {
+	request := &MineRequest{}
+
+	if err := readRequest(r, request); err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if request.Count == 0 {
+		request.Count = 1
+	}
+
+	txns, err := o.ledger.Mine(request.Count)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("mine blocks: %s", err))
+
+		return
+	}
+
+	writeResponse(rw, &MineResponse{BlockHeight: o.ledger.Height(), Transactions: txns}, http.StatusOK)
+}
+
+// reorgHandler swagger:route Post /admin/ledger/reorg admin reorgReq
+//
+// reorgHandler orphans the requested number of transactions (one by default) from the end of the ledger and
+// includes the replacement anchors (if any) in a new block. The operations of the orphaned transactions are
+// rolled back by the observer.
+//
+// Responses:
+//    default: genericError
+//        200: reorgResp
+func (o *Operation) reorgHandler(rw http.ResponseWriter, r *http.Request) {
+	request := &ReorgRequest{}
+
+	if err := readRequest(r, request); err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if request.Count == 0 {
+		request.Count = 1
+	}
+
+	orphaned, replacements, err := o.ledger.Reorg(request.Count, request.Replacements)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("reorg ledger: %s", err))
+
+		return
+	}
+
+	writeResponse(rw, &ReorgResponse{Orphaned: orphaned, Replacements: replacements}, http.StatusOK)
+}
+
+// queueHandler swagger:route Get /admin/queue admin queueReq
+//
+// queueHandler lists the operations that are waiting to be batched, optionally only those of the given namespace.
+//
+// Responses:
+//    default: genericError
+//        200: queueResp
+func (o *Operation) queueHandler(rw http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get(namespaceParam)
+
+	ops := make([]*QueuedOperation, 0)
+
+	for _, w := range o.batchWriters {
+		if namespace != "" && w.Namespace() != namespace {
+			continue
+		}
+
+		for _, e := range w.Pending() {
+			ops = append(ops, newQueuedOperation(w.Namespace(), e))
+		}
+	}
+
+	writeResponse(rw, &QueueResponse{Operations: ops}, http.StatusOK)
+}
+
+// removeQueuedHandler swagger:route Delete /admin/queue/{namespace}/{id} admin removeQueuedReq
+//
+// removeQueuedHandler removes a pending operation from the queue of the given namespace so that it is never anchored.
+//
+// Responses:
+//    default: genericError
+//        200: emptyResp
+func (o *Operation) removeQueuedHandler(rw http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)[namespaceParam]
+
+	id, err := strconv.ParseUint(mux.Vars(r)[idParam], 10, 64)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid operation ID: %s", err))
+
+		return
+	}
+
+	w, err := o.batchWriter(namespace)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusNotFound, err.Error())
+
+		return
+	}
+
+	removed, err := w.RemovePending(id)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("remove operation: %s", err))
+
+		return
+	}
+
+	if !removed {
+		writeErrorResponse(rw, http.StatusNotFound,
+			fmt.Sprintf("operation [%d] is not pending in namespace [%s]", id, namespace))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// cutHandler swagger:route Post /admin/queue/cut admin cutReq
+//
+// cutHandler cuts and anchors all pending operations (optionally only those of the given namespace) without
+// waiting for the batch policy.
+//
+// Responses:
+//    default: genericError
+//        200: cutResp
+func (o *Operation) cutHandler(rw http.ResponseWriter, r *http.Request) {
+	request := &CutRequest{}
+
+	if err := readRequest(r, request); err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	writers := o.batchWriters
+
+	if request.Namespace != "" {
+		w, err := o.batchWriter(request.Namespace)
+		if err != nil {
+			writeErrorResponse(rw, http.StatusNotFound, err.Error())
+
+			return
+		}
+
+		writers = []BatchWriter{w}
+	}
+
+	resp := &CutResponse{Anchored: make(map[string]int)}
+
+	for _, w := range writers {
+		n, err := w.Cut()
+		if err != nil {
+			writeErrorResponse(rw, http.StatusInternalServerError,
+				fmt.Sprintf("cut batch for namespace [%s]: %s", w.Namespace(), err))
+
+			return
+		}
+
+		resp.Anchored[w.Namespace()] = n
+	}
+
+	writeResponse(rw, resp, http.StatusOK)
+}
+
+// didsHandler swagger:route Get /admin/dids admin didsReq
+//
+// didsHandler lists the DIDs that have anchored operations, ordered by namespace and unique suffix. The DIDs may
+// be filtered by namespace and by the ledger time of their create operation (from and to are inclusive). At most
+// limit DIDs are returned; if more DIDs match then the next page is requested by passing the returned next DID
+// in the after parameter.
+//
+// Responses:
+//    default: genericError
+//        200: didsResp
+func (o *Operation) didsHandler(rw http.ResponseWriter, r *http.Request) {
+	q, err := o.parseDIDQuery(r)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	namespaces := make([]string, 0, len(o.opStores))
+
+	for namespace := range o.opStores {
+		if (q.namespace == "" || namespace == q.namespace) && namespace >= q.afterNamespace {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	sort.Strings(namespaces)
+
+	resp := &DIDsResponse{DIDs: make([]*DID, 0)}
+
+	for _, namespace := range namespaces {
+		opStore := o.opStores[namespace]
+
+		for _, suffix := range opStore.Suffixes() {
+			if namespace == q.afterNamespace && suffix <= q.afterSuffix {
+				continue
+			}
+
+			ops, err := opStore.Get(suffix)
+			if err != nil {
+				// the operations were rolled back after the suffixes were listed
+				continue
+			}
+
+			did := newDID(namespace, suffix, ops)
+			if !q.matches(did) {
+				continue
+			}
+
+			if len(resp.DIDs) == q.limit {
+				resp.Next = resp.DIDs[len(resp.DIDs)-1].ID
+
+				writeResponse(rw, resp, http.StatusOK)
+
+				return
+			}
+
+			resp.DIDs = append(resp.DIDs, did)
+		}
+	}
+
+	writeResponse(rw, resp, http.StatusOK)
+}
+
+// didOperationsHandler swagger:route Get /admin/dids/{did}/operations admin didOperationsReq
+//
+// didOperationsHandler returns the anchored operations of the given DID ordered by transaction.
+//
+// Responses:
+//    default: genericError
+//        200: didOperationsResp
+func (o *Operation) didOperationsHandler(rw http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)[didParam]
+
+	namespace, suffix, err := o.splitDID(did)
+	if errors.Is(err, errNamespaceNotFound) {
+		writeErrorResponse(rw, http.StatusNotFound, err.Error())
+
+		return
+	}
+
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	opStore := o.opStores[namespace]
+
+	ops, err := opStore.Get(suffix)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("DID [%s] not found", did))
+
+		return
+	}
+
+	resp := &DIDOperationsResponse{ID: did, Operations: make([]*AnchoredOperation, len(ops))}
+
+	for i, op := range ops {
+		resp.Operations[i] = newAnchoredOperation(op, opinfo.CanonicalReference(o.ledger, op.TransactionNumber))
+	}
+
+	sort.SliceStable(resp.Operations, func(i, j int) bool {
+		if resp.Operations[i].TransactionTime != resp.Operations[j].TransactionTime {
+			return resp.Operations[i].TransactionTime < resp.Operations[j].TransactionTime
+		}
+
+		return resp.Operations[i].TransactionNumber < resp.Operations[j].TransactionNumber
+	})
+
+	writeResponse(rw, resp, http.StatusOK)
+}
+
+// transactionsHandler swagger:route Get /admin/ledger/transactions admin transactionsReq
+//
+// transactionsHandler lists the transactions in the ledger ordered by transaction number. Only the transactions
+// following the transaction number given in since are listed. At most limit transactions are returned; if there
+// are more transactions then the next page is requested by passing the returned next transaction number in since.
+//
+// Responses:
+//    default: genericError
+//        200: transactionsResp
+func (o *Operation) transactionsHandler(rw http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	since := -1
+
+	if v := values.Get(sinceParam); v != "" {
+		n, err := strconv.ParseUint(v, 10, 31)
+		if err != nil {
+			writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid %s: %s", sinceParam, err))
+
+			return
+		}
+
+		since = int(n)
+	}
+
+	limit, err := parseLimit(values.Get(limitParam))
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	resp := &TransactionsResponse{Transactions: make([]*txn.SidetreeTxn, 0)}
+
+	for more := true; more && len(resp.Transactions) < limit; {
+		var t *txn.SidetreeTxn
+
+		more, t = o.ledger.Read(since)
+		if t == nil {
+			break
+		}
+
+		resp.Transactions = append(resp.Transactions, t)
+		since = int(t.TransactionNumber)
+
+		if more && len(resp.Transactions) == limit {
+			next := t.TransactionNumber
+			resp.Next = &next
+		}
+	}
+
+	writeResponse(rw, resp, http.StatusOK)
+}
+
+// transactionHandler swagger:route Get /admin/ledger/transactions/{number} admin transactionReq
+//
+// transactionHandler returns the transaction with the given number along with the core index file referenced
+// by its anchor string.
+//
+// Responses:
+//    default: genericError
+//        200: transactionResp
+func (o *Operation) transactionHandler(rw http.ResponseWriter, r *http.Request) {
+	number, err := strconv.ParseUint(mux.Vars(r)[numberParam], 10, 31)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid transaction number: %s", err))
+
+		return
+	}
+
+	_, t := o.ledger.Read(int(number) - 1)
+	if t == nil {
+		writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("transaction [%d] not found", number))
+
+		return
+	}
+
+	resp := &TransactionResponse{Transaction: t}
+
+	// the anchor string of the mock ledger is "<number of operations>.<core index file URI>"
+	anchorData, err := txnprovider.ParseAnchorData(t.AnchorString)
+	if err != nil {
+		logger.Debugf("Unable to parse anchor string of transaction [%d]: %s", number, err)
+	} else {
+		resp.NumberOfOperations = anchorData.NumberOfOperations
+		resp.CoreIndexFileURI = anchorData.CoreIndexFileURI
+		resp.CoreIndexFile = casContentPath + anchorData.CoreIndexFileURI
+		resp.DecodedCoreIndexFile = casContentPath + anchorData.CoreIndexFileURI + "/decoded"
+	}
+
+	writeResponse(rw, resp, http.StatusOK)
+}
+
+// webhooksHandler swagger:route Get /admin/webhooks admin webhooksReq
+//
+// webhooksHandler returns the webhook subscriptions (without their secrets).
+//
+// Responses:
+//    default: genericError
+//        200: webhooksResp
+func (o *Operation) webhooksHandler(rw http.ResponseWriter, _ *http.Request) {
+	writeResponse(rw, &WebhooksResponse{Webhooks: o.webhooks.Subscriptions()}, http.StatusOK)
+}
+
+// subscribeHandler swagger:route Post /admin/webhooks admin subscribeReq
+//
+// subscribeHandler registers a webhook that is invoked when operations are queued, anchored and processed
+// (optionally only for the given DID suffixes and operation types). The subscription is returned along with the
+// secret used to sign the payloads.
+//
+// Responses:
+//    default: genericError
+//        201: subscribeResp
+func (o *Operation) subscribeHandler(rw http.ResponseWriter, r *http.Request) {
+	request := &webhook.Subscription{}
+
+	if err := readRequest(r, request); err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	sub, err := o.webhooks.Subscribe(request)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("subscribe webhook: %s", err))
+
+		return
+	}
+
+	writeResponse(rw, sub, http.StatusCreated)
+}
+
+// unsubscribeHandler swagger:route Delete /admin/webhooks/{id} admin unsubscribeReq
+//
+// unsubscribeHandler removes the webhook subscription with the given ID.
+//
+// Responses:
+//    default: genericError
+//        200: emptyResp
+func (o *Operation) unsubscribeHandler(rw http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)[idParam]
+
+	if !o.webhooks.Unsubscribe(id) {
+		writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("webhook [%s] not found", id))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (o *Operation) batchWriter(namespace string) (BatchWriter, error) {
+	for _, w := range o.batchWriters {
+		if w.Namespace() == namespace {
+			return w, nil
+		}
+	}
+
+	return nil, fmt.Errorf("namespace [%s] not found", namespace)
+}
+
+func newQueuedOperation(namespace string, e *opqueue.Entry) *QueuedOperation {
+	return &QueuedOperation{
+		ID:              e.ID,
+		Namespace:       namespace,
+		UniqueSuffix:    e.Operation.UniqueSuffix,
+		Type:            opinfo.Type(e.Operation.OperationRequest),
+		Size:            len(e.Operation.OperationRequest),
+		ProtocolVersion: e.Operation.ProtocolVersion,
+		QueuedTime:      e.QueuedTime,
+	}
+}
+
+// didQuery holds the parameters of a DID listing
+type didQuery struct {
+	namespace      string
+	from           *uint64
+	to             *uint64
+	afterNamespace string
+	afterSuffix    string
+	limit          int
+}
+
+func (o *Operation) parseDIDQuery(r *http.Request) (*didQuery, error) {
+	values := r.URL.Query()
+
+	q := &didQuery{
+		namespace: values.Get(namespaceParam),
+	}
+
+	var err error
+
+	if q.from, err = parseUintParam(values.Get(fromParam), fromParam); err != nil {
+		return nil, err
+	}
+
+	if q.to, err = parseUintParam(values.Get(toParam), toParam); err != nil {
+		return nil, err
+	}
+
+	if after := values.Get(afterParam); after != "" {
+		if q.afterNamespace, q.afterSuffix, err = o.splitDID(after); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", afterParam, err)
+		}
+	}
+
+	if q.limit, err = parseLimit(values.Get(limitParam)); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// parseLimit parses the maximum number of items of a page. The default limit is returned if no limit is given.
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("invalid %s: must be a number between 1 and %d", limitParam, maxLimit)
+	}
+
+	return limit, nil
+}
+
+// matches returns true if the given DID was created within the time range of the query
+func (q *didQuery) matches(did *DID) bool {
+	if q.from == nil && q.to == nil {
+		return true
+	}
+
+	if did.CreatedTime == nil {
+		return false
+	}
+
+	return (q.from == nil || *did.CreatedTime >= *q.from) && (q.to == nil || *did.CreatedTime <= *q.to)
+}
+
+// splitDID splits the given short-form DID into the namespace hosted by the node and the unique suffix. Namespaces
+// may contain colons (and an alias may extend a namespace, e.g. did:sidetree and did:sidetree:test) so the DID is
+// matched against the namespaces and aliases of the node and the longest match wins. The namespace of an alias is
+// returned for a DID with the alias.
+func (o *Operation) splitDID(did string) (string, string, error) {
+	if !strings.HasPrefix(did, "did:") {
+		return "", "", fmt.Errorf("[%s] is not a DID", did)
+	}
+
+	var match, namespace string
+
+	for ns := range o.opStores {
+		if strings.HasPrefix(did, ns+":") && len(ns) > len(match) {
+			match, namespace = ns, ns
+		}
+	}
+
+	for alias, ns := range o.aliases {
+		if strings.HasPrefix(did, alias+":") && len(alias) > len(match) {
+			match, namespace = alias, ns
+		}
+	}
+
+	if match == "" {
+		return "", "", fmt.Errorf("%w for DID [%s]", errNamespaceNotFound, did)
+	}
+
+	suffix := did[len(match)+1:]
+	if suffix == "" || strings.Contains(suffix, ":") {
+		return "", "", fmt.Errorf("[%s] is not a short-form DID of namespace [%s]", did, match)
+	}
+
+	return namespace, suffix, nil
+}
+
+func parseUintParam(value, name string) (*uint64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return &v, nil
+}
+
+// newDID summarizes the given operations of a DID. The operations are in the order in which they were anchored.
+func newDID(namespace, suffix string, ops []*operation.AnchoredOperation) *DID {
+	did := &DID{
+		ID:                opinfo.DID(namespace, suffix),
+		Namespace:         namespace,
+		UniqueSuffix:      suffix,
+		Operations:        len(ops),
+		LastOperationType: ops[len(ops)-1].Type,
+	}
+
+	for _, op := range ops {
+		switch op.Type {
+		case operation.TypeCreate:
+			if did.CreatedTime == nil {
+				txnTime, txnNumber := op.TransactionTime, op.TransactionNumber
+				did.CreatedTime = &txnTime
+				did.CreatedTransactionNumber = &txnNumber
+			}
+		case operation.TypeDeactivate:
+			did.Deactivated = true
+		}
+	}
+
+	return did
+}
+
+func newAnchoredOperation(op *operation.AnchoredOperation, canonicalReference string) *AnchoredOperation {
+	request := json.RawMessage(op.OperationRequest)
+
+	// operation requests are JSON but a request that isn't is returned as a string rather than dropped
+	if !json.Valid(request) {
+		request, _ = json.Marshal(string(op.OperationRequest)) // nolint: errcheck
+	}
+
+	return &AnchoredOperation{
+		Type:                 op.Type,
+		UniqueSuffix:         op.UniqueSuffix,
+		TransactionTime:      op.TransactionTime,
+		TransactionNumber:    op.TransactionNumber,
+		ProtocolVersion:      op.ProtocolVersion,
+		CanonicalReference:   canonicalReference,
+		EquivalentReferences: op.EquivalentReferences,
+		AnchorOrigin:         op.AnchorOrigin,
+		Request:              request,
+	}
+}
+
+// readRequest unmarshals the request body into the given value. An empty body is allowed.
+func readRequest(r *http.Request, v interface{}) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("read request: %w", err)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	return nil
+}
+
+// writeResponse writes response.
+func writeResponse(rw http.ResponseWriter, v interface{}, status int) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(v)
+	if err != nil {
+		logger.Errorf("unable to send a response: %v", err)
+	}
+}
+
+// writeErrorResponse write error resp.
+func writeErrorResponse(rw http.ResponseWriter, status int, msg string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(ErrorResponse{
+		Message: msg,
+	})
+	if err != nil {
+		logger.Errorf("Unable to send error message, %s", err)
+	}
+}
+
+// newHTTPHandler returns instance of HTTPHandler which can be used to handle http requests.
+func (o *Operation) newHTTPHandler(path, method string, handle common.HTTPRequestHandler) common.HTTPHandler {
+	return &httpHandler{path: path, method: method, handle: handle, token: o.token}
+}
+
+// HTTPHandler contains REST API handling details which can be used to build routers.
+// for http requests for given path.
+type httpHandler struct {
+	path   string
+	method string
+	handle common.HTTPRequestHandler
+	token  string
+}
+
+// Path returns http request path.
+func (h *httpHandler) Path() string {
+	return h.path
+}
+
+// Method returns http request method type.
+func (h *httpHandler) Method() string {
+	return h.method
+}
+
+// Handler returns http request handle func.
+func (h *httpHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+// Token returns the bearer token that protects the handler.
+func (h *httpHandler) Token() string {
+	return h.token
+}
@@ -0,0 +1,982 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/admin/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	"github.com/trustbloc/sidetree-mock/pkg/opqueue"
+	"github.com/trustbloc/sidetree-mock/pkg/webhook"
+)
+
+const (
+	snapshotEndpoint = "/admin/snapshot"
+	restoreEndpoint  = "/admin/restore"
+	resetEndpoint    = "/admin/reset"
+	blocksEndpoint   = "/admin/ledger/blocks"
+	reorgEndpoint    = "/admin/ledger/reorg"
+	queueEndpoint    = "/admin/queue"
+	queueOpEndpoint  = "/admin/queue/{namespace}/{id}"
+	cutEndpoint      = "/admin/queue/cut"
+	didsEndpoint     = "/admin/dids"
+	didOpsEndpoint   = "/admin/dids/{did}/operations"
+	txnsEndpoint     = "/admin/ledger/transactions"
+	txnEndpoint      = "/admin/ledger/transactions/{number}"
+	webhooksEndpoint = "/admin/webhooks"
+	webhookEndpoint  = "/admin/webhooks/{id}"
+)
+
+func TestGetRESTHandlers(t *testing.T) {
+	c := restapi.New(&restapi.Config{Token: "tk1"})
+	require.Equal(t, 15, len(c.GetRESTHandlers()))
+
+	for _, h := range c.GetRESTHandlers() {
+		tokenHandler, ok := h.(interface{ Token() string })
+		require.True(t, ok)
+		require.Equal(t, "tk1", tokenHandler.Token())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{StateManager: &mockStateManager{archive: []byte("archive")}})
+
+		handler := getHandler(t, c, snapshotEndpoint, http.MethodGet)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodGet, snapshotEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+		require.Equal(t, "archive", rr.Body.String())
+	})
+
+	t.Run("export error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{StateManager: &mockStateManager{err: errors.New("injected error")}})
+
+		handler := getHandler(t, c, snapshotEndpoint, http.MethodGet)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodGet, snapshotEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sm := &mockStateManager{}
+		c := restapi.New(&restapi.Config{StateManager: sm})
+
+		handler := getHandler(t, c, restoreEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, restoreEndpoint, []byte("archive"), nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "archive", string(sm.archive))
+	})
+
+	t.Run("import error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{StateManager: &mockStateManager{err: errors.New("injected error")}})
+
+		handler := getHandler(t, c, restoreEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, restoreEndpoint, []byte("archive"), nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestReset(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sm := &mockStateManager{archive: []byte("archive")}
+		c := restapi.New(&restapi.Config{StateManager: sm})
+
+		handler := getHandler(t, c, resetEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, resetEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.True(t, sm.reset)
+	})
+
+	t.Run("reset error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{StateManager: &mockStateManager{err: errors.New("injected error")}})
+
+		handler := getHandler(t, c, resetEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, resetEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestMine(t *testing.T) {
+	t.Run("default count", func(t *testing.T) {
+		l := &mockLedger{}
+		c := restapi.New(&restapi.Config{Ledger: l})
+
+		handler := getHandler(t, c, blocksEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, blocksEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, 1, l.blocks)
+
+		resp := &restapi.MineResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, uint64(1), resp.BlockHeight)
+		require.Len(t, resp.Transactions, 1)
+	})
+
+	t.Run("with count", func(t *testing.T) {
+		l := &mockLedger{}
+		c := restapi.New(&restapi.Config{Ledger: l})
+
+		handler := getHandler(t, c, blocksEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, blocksEndpoint, []byte(`{"count":5}`), nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, 5, l.blocks)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Ledger: &mockLedger{}})
+
+		handler := getHandler(t, c, blocksEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, blocksEndpoint, []byte("{"), nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid request")
+	})
+
+	t.Run("mine error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Ledger: &mockLedger{err: errors.New("injected error")}})
+
+		handler := getHandler(t, c, blocksEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, blocksEndpoint, []byte(`{"count":-1}`), nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestReorg(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		l := &mockLedger{}
+		c := restapi.New(&restapi.Config{Ledger: l})
+
+		handler := getHandler(t, c, reorgEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, reorgEndpoint,
+			[]byte(`{"count":2,"replacements":[{"anchor":"1.replacement","protocolVersion":0}]}`), nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, 2, l.orphaned)
+		require.Len(t, l.replacements, 1)
+		require.Equal(t, "1.replacement", l.replacements[0].Anchor)
+
+		resp := &restapi.ReorgResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Orphaned, 2)
+		require.Len(t, resp.Replacements, 1)
+	})
+
+	t.Run("default count", func(t *testing.T) {
+		l := &mockLedger{}
+		c := restapi.New(&restapi.Config{Ledger: l})
+
+		handler := getHandler(t, c, reorgEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, reorgEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, 1, l.orphaned)
+		require.Empty(t, l.replacements)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Ledger: &mockLedger{}})
+
+		handler := getHandler(t, c, reorgEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, reorgEndpoint, []byte("{"), nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid request")
+	})
+
+	t.Run("reorg error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Ledger: &mockLedger{err: errors.New("injected error")}})
+
+		handler := getHandler(t, c, reorgEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, reorgEndpoint, nil, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestQueue(t *testing.T) {
+	c := restapi.New(&restapi.Config{BatchWriters: batchWriters(newMockBatchWriters())})
+
+	handler := getHandler(t, c, queueEndpoint, http.MethodGet)
+
+	t.Run("all namespaces", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), http.MethodGet, queueEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.QueueResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Operations, 3)
+
+		op := resp.Operations[0]
+		require.Equal(t, uint64(1), op.ID)
+		require.Equal(t, "did:ns1", op.Namespace)
+		require.Equal(t, operation.TypeCreate, op.Type)
+		require.Equal(t, "suffix1", op.UniqueSuffix)
+		require.Equal(t, len(`{"type":"create"}`), op.Size)
+		require.False(t, op.QueuedTime.IsZero())
+
+		// the type of an operation request that can't be parsed is omitted
+		require.Empty(t, resp.Operations[1].Type)
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), http.MethodGet, queueEndpoint+"?namespace=did:ns2", nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.QueueResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Operations, 1)
+		require.Equal(t, "did:ns2", resp.Operations[0].Namespace)
+		require.Equal(t, operation.TypeUpdate, resp.Operations[0].Type)
+	})
+}
+
+func TestRemoveQueued(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		writers := newMockBatchWriters()
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(writers)})
+
+		handler := getHandler(t, c, queueOpEndpoint, http.MethodDelete)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodDelete, "/admin/queue/did:ns1/2", nil,
+			map[string]string{"namespace": "did:ns1", "id": "2"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Len(t, writers[0].Pending(), 1)
+
+		rr = serveHTTP(t, handler.Handler(), http.MethodDelete, "/admin/queue/did:ns1/2", nil,
+			map[string]string{"namespace": "did:ns1", "id": "2"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "operation [2] is not pending in namespace [did:ns1]")
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(newMockBatchWriters())})
+
+		handler := getHandler(t, c, queueOpEndpoint, http.MethodDelete)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodDelete, "/admin/queue/did:ns1/x", nil,
+			map[string]string{"namespace": "did:ns1", "id": "x"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid operation ID")
+	})
+
+	t.Run("unknown namespace", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(newMockBatchWriters())})
+
+		handler := getHandler(t, c, queueOpEndpoint, http.MethodDelete)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodDelete, "/admin/queue/did:other/1", nil,
+			map[string]string{"namespace": "did:other", "id": "1"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "namespace [did:other] not found")
+	})
+
+	t.Run("remove error", func(t *testing.T) {
+		writers := newMockBatchWriters()
+		writers[0].err = errors.New("injected error")
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(writers)})
+
+		handler := getHandler(t, c, queueOpEndpoint, http.MethodDelete)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodDelete, "/admin/queue/did:ns1/1", nil,
+			map[string]string{"namespace": "did:ns1", "id": "1"})
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestCut(t *testing.T) {
+	t.Run("all namespaces", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(newMockBatchWriters())})
+
+		handler := getHandler(t, c, cutEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, cutEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.CutResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, map[string]int{"did:ns1": 2, "did:ns2": 1}, resp.Anchored)
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		writers := newMockBatchWriters()
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(writers)})
+
+		handler := getHandler(t, c, cutEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, cutEndpoint, []byte(`{"namespace":"did:ns2"}`), nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.CutResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, map[string]int{"did:ns2": 1}, resp.Anchored)
+		require.Len(t, writers[0].Pending(), 2)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(newMockBatchWriters())})
+
+		handler := getHandler(t, c, cutEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, cutEndpoint, []byte("{"), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid request")
+	})
+
+	t.Run("unknown namespace", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(newMockBatchWriters())})
+
+		handler := getHandler(t, c, cutEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, cutEndpoint, []byte(`{"namespace":"did:other"}`), nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("cut error", func(t *testing.T) {
+		writers := newMockBatchWriters()
+		writers[1].err = errors.New("injected error")
+		c := restapi.New(&restapi.Config{BatchWriters: batchWriters(writers)})
+
+		handler := getHandler(t, c, cutEndpoint, http.MethodPost)
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodPost, cutEndpoint, nil, nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "cut batch for namespace [did:ns2]: injected error")
+	})
+}
+
+func TestDIDs(t *testing.T) {
+	c := restapi.New(&restapi.Config{OperationStores: newOperationStores(t)})
+
+	handler := getHandler(t, c, didsEndpoint, http.MethodGet)
+
+	list := func(t *testing.T, query string) *restapi.DIDsResponse {
+		t.Helper()
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodGet, didsEndpoint+query, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.DIDsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+		return resp
+	}
+
+	ids := func(dids []*restapi.DID) []string {
+		var result []string
+		for _, d := range dids {
+			result = append(result, d.ID)
+		}
+
+		return result
+	}
+
+	t.Run("all namespaces", func(t *testing.T) {
+		resp := list(t, "")
+		require.Empty(t, resp.Next)
+		require.Equal(t, []string{"did:ns1:suffix1", "did:ns1:suffix2", "did:ns1:suffix3", "did:ns2:suffix1"},
+			ids(resp.DIDs))
+
+		d := resp.DIDs[0]
+		require.Equal(t, "did:ns1", d.Namespace)
+		require.Equal(t, "suffix1", d.UniqueSuffix)
+		require.Equal(t, 3, d.Operations)
+		require.Equal(t, uint64(1), *d.CreatedTime)
+		require.Equal(t, uint64(10), *d.CreatedTransactionNumber)
+		require.Equal(t, operation.TypeDeactivate, d.LastOperationType)
+		require.True(t, d.Deactivated)
+
+		d = resp.DIDs[1]
+		require.Equal(t, 1, d.Operations)
+		require.Equal(t, operation.TypeCreate, d.LastOperationType)
+		require.False(t, d.Deactivated)
+
+		// the create operation of suffix3 isn't anchored
+		require.Nil(t, resp.DIDs[2].CreatedTime)
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		require.Equal(t, []string{"did:ns2:suffix1"}, ids(list(t, "?namespace=did:ns2").DIDs))
+		require.Empty(t, list(t, "?namespace=did:unknown").DIDs)
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		require.Equal(t, []string{"did:ns1:suffix2", "did:ns2:suffix1"}, ids(list(t, "?from=2").DIDs))
+		require.Equal(t, []string{"did:ns1:suffix1", "did:ns1:suffix2"}, ids(list(t, "?to=2").DIDs))
+		require.Equal(t, []string{"did:ns1:suffix2"}, ids(list(t, "?from=2&to=2").DIDs))
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		resp := list(t, "?limit=3")
+		require.Equal(t, []string{"did:ns1:suffix1", "did:ns1:suffix2", "did:ns1:suffix3"}, ids(resp.DIDs))
+		require.Equal(t, "did:ns1:suffix3", resp.Next)
+
+		resp = list(t, "?limit=3&after="+resp.Next)
+		require.Equal(t, []string{"did:ns2:suffix1"}, ids(resp.DIDs))
+		require.Empty(t, resp.Next)
+
+		// the last page is full but there are no more DIDs
+		resp = list(t, "?limit=1&after=did:ns1:suffix3")
+		require.Len(t, resp.DIDs, 1)
+		require.Empty(t, resp.Next)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for query, msg := range map[string]string{
+			"?from=x":                "invalid from",
+			"?to=-1":                 "invalid to",
+			"?limit=0":               "invalid limit",
+			"?limit=x":               "invalid limit",
+			"?after=suffix":          "invalid after: [suffix] is not a DID",
+			"?after=did:":            "invalid after",
+			"?after=did:ns3:suffix1": "invalid after: namespace not found for DID [did:ns3:suffix1]",
+		} {
+			rr := serveHTTP(t, handler.Handler(), http.MethodGet, didsEndpoint+query, nil, nil)
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+			require.Contains(t, rr.Body.String(), msg, query)
+		}
+	})
+}
+
+func TestDIDOperations(t *testing.T) {
+	opStore := mocks.NewMockOperationStore()
+	require.NoError(t, opStore.Put([]*operation.AnchoredOperation{
+		{
+			Type:              operation.TypeUpdate,
+			UniqueSuffix:      "suffix1",
+			OperationRequest:  []byte("invalid"),
+			TransactionTime:   2,
+			TransactionNumber: 1,
+			ProtocolVersion:   1,
+		},
+		{
+			Type:              operation.TypeCreate,
+			UniqueSuffix:      "suffix1",
+			OperationRequest:  []byte(`{"type":"create","suffixData":{"deltaHash":"hash"}}`),
+			TransactionTime:   1,
+			TransactionNumber: 0,
+		},
+	}))
+
+	// the canonical reference of an operation is the canonical reference of its transaction
+	ledger := observer.NewAnchorWriter("did:ns1")
+	require.NoError(t, ledger.WriteAnchor("1.ref1", nil, nil, 0))
+	require.NoError(t, ledger.WriteAnchor("1.ref2", nil, nil, 0))
+
+	// did:ns1:test is a namespace that extends did:ns1 and did:alias is an alias of did:ns1
+	testOpStore := mocks.NewMockOperationStore()
+	require.NoError(t, testOpStore.Put([]*operation.AnchoredOperation{
+		{Type: operation.TypeCreate, UniqueSuffix: "suffix1", TransactionNumber: 2},
+	}))
+
+	c := restapi.New(&restapi.Config{
+		Ledger:          ledger,
+		OperationStores: map[string]restapi.OperationStore{"did:ns1": opStore, "did:ns1:test": testOpStore},
+		Aliases:         map[string]string{"did:alias": "did:ns1"},
+	})
+
+	handler := getHandler(t, c, didOpsEndpoint, http.MethodGet)
+
+	get := func(did string) *httptest.ResponseRecorder {
+		return serveHTTP(t, handler.Handler(), http.MethodGet, "/admin/dids/"+did+"/operations", nil,
+			map[string]string{"did": did})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr := get("did:ns1:suffix1")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.DIDOperationsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, "did:ns1:suffix1", resp.ID)
+		require.Len(t, resp.Operations, 2)
+
+		op := resp.Operations[0]
+		require.Equal(t, operation.TypeCreate, op.Type)
+		require.Equal(t, "suffix1", op.UniqueSuffix)
+		require.Equal(t, uint64(1), op.TransactionTime)
+		require.Equal(t, uint64(0), op.TransactionNumber)
+		require.Equal(t, "ref1", op.CanonicalReference)
+		require.JSONEq(t, `{"type":"create","suffixData":{"deltaHash":"hash"}}`, string(op.Request))
+
+		op = resp.Operations[1]
+		require.Equal(t, operation.TypeUpdate, op.Type)
+		require.Equal(t, uint64(1), op.ProtocolVersion)
+		require.Equal(t, "ref2", op.CanonicalReference)
+		require.Equal(t, `"invalid"`, string(op.Request))
+	})
+
+	t.Run("longest namespace", func(t *testing.T) {
+		rr := get("did:ns1:test:suffix1")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.DIDOperationsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Operations, 1)
+		require.Equal(t, uint64(2), resp.Operations[0].TransactionNumber)
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		rr := get("did:alias:suffix1")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.DIDOperationsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, "did:alias:suffix1", resp.ID)
+		require.Len(t, resp.Operations, 2)
+	})
+
+	t.Run("invalid DID", func(t *testing.T) {
+		for did, msg := range map[string]string{
+			"suffix1":                  "[suffix1] is not a DID",
+			"did:ns1:":                 "[did:ns1:] is not a short-form DID of namespace [did:ns1]",
+			"did:ns1:suffix1:longform": "[did:ns1:suffix1:longform] is not a short-form DID of namespace [did:ns1]",
+		} {
+			rr := get(did)
+			require.Equal(t, http.StatusBadRequest, rr.Code, did)
+			require.Contains(t, rr.Body.String(), msg, did)
+		}
+	})
+
+	t.Run("unknown namespace", func(t *testing.T) {
+		rr := get("did:ns2:suffix1")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "namespace not found for DID [did:ns2:suffix1]")
+	})
+
+	t.Run("unknown DID", func(t *testing.T) {
+		rr := get("did:ns1:suffix2")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "DID [did:ns1:suffix2] not found")
+	})
+}
+
+func TestTransactions(t *testing.T) {
+	ledger := observer.NewAnchorWriter("did:ns1")
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ledger.WriteAnchor(fmt.Sprintf("%d.EiCoreIndex%d", i+1, i), nil, nil, 0))
+	}
+
+	c := restapi.New(&restapi.Config{Ledger: ledger})
+
+	handler := getHandler(t, c, txnsEndpoint, http.MethodGet)
+
+	list := func(t *testing.T, query string) *restapi.TransactionsResponse {
+		t.Helper()
+
+		rr := serveHTTP(t, handler.Handler(), http.MethodGet, txnsEndpoint+query, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.TransactionsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+		return resp
+	}
+
+	t.Run("all transactions", func(t *testing.T) {
+		resp := list(t, "")
+		require.Len(t, resp.Transactions, 5)
+		require.Nil(t, resp.Next)
+		require.Equal(t, "1.EiCoreIndex0", resp.Transactions[0].AnchorString)
+		require.Equal(t, "did:ns1", resp.Transactions[0].Namespace)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		resp := list(t, "?limit=2")
+		require.Len(t, resp.Transactions, 2)
+		require.Equal(t, uint64(1), *resp.Next)
+
+		resp = list(t, fmt.Sprintf("?limit=2&since=%d", *resp.Next))
+		require.Len(t, resp.Transactions, 2)
+		require.Equal(t, uint64(2), resp.Transactions[0].TransactionNumber)
+		require.Equal(t, uint64(3), *resp.Next)
+
+		resp = list(t, fmt.Sprintf("?limit=2&since=%d", *resp.Next))
+		require.Len(t, resp.Transactions, 1)
+		require.Nil(t, resp.Next)
+
+		require.Empty(t, list(t, "?since=4").Transactions)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for query, msg := range map[string]string{
+			"?since=x":  "invalid since",
+			"?since=-1": "invalid since",
+			"?limit=0":  "invalid limit",
+		} {
+			rr := serveHTTP(t, handler.Handler(), http.MethodGet, txnsEndpoint+query, nil, nil)
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+			require.Contains(t, rr.Body.String(), msg, query)
+		}
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		handler := getHandler(t, c, txnEndpoint, http.MethodGet)
+
+		get := func(number string) *httptest.ResponseRecorder {
+			return serveHTTP(t, handler.Handler(), http.MethodGet, txnsEndpoint+"/"+number, nil,
+				map[string]string{"number": number})
+		}
+
+		rr := get("2")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.TransactionResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, uint64(2), resp.Transaction.TransactionNumber)
+		require.Equal(t, 3, resp.NumberOfOperations)
+		require.Equal(t, "EiCoreIndex2", resp.CoreIndexFileURI)
+		require.Equal(t, "/cas/EiCoreIndex2", resp.CoreIndexFile)
+		require.Equal(t, "/cas/EiCoreIndex2/decoded", resp.DecodedCoreIndexFile)
+
+		rr = get("5")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "transaction [5] not found")
+
+		rr = get("x")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid transaction number")
+	})
+
+	t.Run("anchor string can't be parsed", func(t *testing.T) {
+		ledger := observer.NewAnchorWriter("did:ns1")
+		require.NoError(t, ledger.WriteAnchor("anchor", nil, nil, 0))
+
+		c := restapi.New(&restapi.Config{Ledger: ledger})
+
+		rr := serveHTTP(t, getHandler(t, c, txnEndpoint, http.MethodGet).Handler(), http.MethodGet,
+			txnsEndpoint+"/0", nil, map[string]string{"number": "0"})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.TransactionResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, "anchor", resp.Transaction.AnchorString)
+		require.Empty(t, resp.CoreIndexFile)
+	})
+}
+
+func TestWebhooks(t *testing.T) {
+	c := restapi.New(&restapi.Config{Webhooks: webhook.New()})
+
+	subscribe := getHandler(t, c, webhooksEndpoint, http.MethodPost).Handler()
+	list := getHandler(t, c, webhooksEndpoint, http.MethodGet).Handler()
+	unsubscribe := getHandler(t, c, webhookEndpoint, http.MethodDelete).Handler()
+
+	t.Run("success", func(t *testing.T) {
+		rr := serveHTTP(t, subscribe, http.MethodPost, webhooksEndpoint,
+			[]byte(`{"url":"https://example.com/hook","suffixes":["suffix1"],"operationTypes":["update"]}`), nil)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		sub := &webhook.Subscription{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), sub))
+		require.NotEmpty(t, sub.ID)
+		require.NotEmpty(t, sub.Secret)
+		require.Equal(t, "https://example.com/hook", sub.URL)
+		require.Equal(t, []string{"suffix1"}, sub.Suffixes)
+		require.Equal(t, []operation.Type{operation.TypeUpdate}, sub.OperationTypes)
+
+		rr = serveHTTP(t, list, http.MethodGet, webhooksEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := &restapi.WebhooksResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Webhooks, 1)
+		require.Equal(t, sub.ID, resp.Webhooks[0].ID)
+		require.Empty(t, resp.Webhooks[0].Secret)
+
+		rr = serveHTTP(t, unsubscribe, http.MethodDelete, "/admin/webhooks/"+sub.ID, nil,
+			map[string]string{"id": sub.ID})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = serveHTTP(t, unsubscribe, http.MethodDelete, "/admin/webhooks/"+sub.ID, nil,
+			map[string]string{"id": sub.ID})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "not found")
+
+		rr = serveHTTP(t, list, http.MethodGet, webhooksEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"webhooks":[]}`, rr.Body.String())
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		rr := serveHTTP(t, subscribe, http.MethodPost, webhooksEndpoint, []byte("{"), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid request")
+
+		rr = serveHTTP(t, subscribe, http.MethodPost, webhooksEndpoint, nil, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "must be an absolute http or https URL")
+
+		rr = serveHTTP(t, subscribe, http.MethodPost, webhooksEndpoint,
+			[]byte(`{"url":"https://example.com/hook","operationTypes":["other"]}`), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "operation type [other] is not supported")
+	})
+}
+
+func newOperationStores(t *testing.T) map[string]restapi.OperationStore {
+	t.Helper()
+
+	newOp := func(opType operation.Type, suffix string, txnTime, txnNumber uint64) *operation.AnchoredOperation {
+		return &operation.AnchoredOperation{
+			Type:              opType,
+			UniqueSuffix:      suffix,
+			TransactionTime:   txnTime,
+			TransactionNumber: txnNumber,
+		}
+	}
+
+	ns1 := mocks.NewMockOperationStore()
+	require.NoError(t, ns1.Put([]*operation.AnchoredOperation{
+		newOp(operation.TypeCreate, "suffix1", 1, 10),
+		newOp(operation.TypeUpdate, "suffix1", 2, 20),
+		newOp(operation.TypeDeactivate, "suffix1", 3, 30),
+		newOp(operation.TypeCreate, "suffix2", 2, 20),
+		newOp(operation.TypeUpdate, "suffix3", 2, 20),
+	}))
+
+	ns2 := mocks.NewMockOperationStore()
+	require.NoError(t, ns2.Put([]*operation.AnchoredOperation{newOp(operation.TypeCreate, "suffix1", 3, 30)}))
+
+	return map[string]restapi.OperationStore{"did:ns1": ns1, "did:ns2": ns2}
+}
+
+type mockBatchWriter struct {
+	namespace string
+	pending   []*opqueue.Entry
+	err       error
+}
+
+func newMockBatchWriters() []*mockBatchWriter {
+	newEntry := func(id uint64, suffix, request string) *opqueue.Entry {
+		return &opqueue.Entry{
+			ID: id,
+			Operation: &operation.QueuedOperationAtTime{
+				QueuedOperation: operation.QueuedOperation{UniqueSuffix: suffix, OperationRequest: []byte(request)},
+			},
+			QueuedTime: time.Now(),
+		}
+	}
+
+	return []*mockBatchWriter{
+		{
+			namespace: "did:ns1",
+			pending:   []*opqueue.Entry{newEntry(1, "suffix1", `{"type":"create"}`), newEntry(2, "suffix2", "invalid")},
+		},
+		{
+			namespace: "did:ns2",
+			pending:   []*opqueue.Entry{newEntry(1, "suffix3", `{"type":"update"}`)},
+		},
+	}
+}
+
+func batchWriters(writers []*mockBatchWriter) []restapi.BatchWriter {
+	bw := make([]restapi.BatchWriter, len(writers))
+	for i, w := range writers {
+		bw[i] = w
+	}
+
+	return bw
+}
+
+func (m *mockBatchWriter) Namespace() string {
+	return m.namespace
+}
+
+func (m *mockBatchWriter) Pending() []*opqueue.Entry {
+	return m.pending
+}
+
+func (m *mockBatchWriter) RemovePending(id uint64) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+
+	for i, e := range m.pending {
+		if e.ID == id {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *mockBatchWriter) Cut() (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	n := len(m.pending)
+	m.pending = nil
+
+	return n, nil
+}
+
+type mockLedger struct {
+	blocks       int
+	orphaned     int
+	replacements []*observer.Anchor
+	err          error
+}
+
+func (m *mockLedger) Reorg(count int, replacements []*observer.Anchor) ([]*txn.SidetreeTxn, []*txn.SidetreeTxn, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+
+	m.orphaned = count
+	m.replacements = replacements
+
+	orphaned := make([]*txn.SidetreeTxn, count)
+	for i := range orphaned {
+		orphaned[i] = &txn.SidetreeTxn{TransactionNumber: uint64(i)}
+	}
+
+	var replaced []*txn.SidetreeTxn
+	for _, r := range replacements {
+		replaced = append(replaced, &txn.SidetreeTxn{AnchorString: r.Anchor})
+	}
+
+	return orphaned, replaced, nil
+}
+
+func (m *mockLedger) Mine(blocks int) ([]*txn.SidetreeTxn, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	m.blocks += blocks
+
+	return []*txn.SidetreeTxn{{AnchorString: "1.anchor"}}, nil
+}
+
+func (m *mockLedger) Height() uint64 {
+	return uint64(m.blocks)
+}
+
+func (m *mockLedger) Read(int) (bool, *txn.SidetreeTxn) {
+	return false, nil
+}
+
+type mockStateManager struct {
+	archive []byte
+	reset   bool
+	err     error
+}
+
+func (m *mockStateManager) Reset() error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.reset = true
+
+	return nil
+}
+
+func (m *mockStateManager) Export(w io.Writer) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	_, err := w.Write(m.archive)
+
+	return err
+}
+
+func (m *mockStateManager) Import(r io.Reader) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	var err error
+	m.archive, err = ioutil.ReadAll(r)
+
+	return err
+}
+
+//nolint:unparam
+func serveHTTP(t *testing.T, handler common.HTTPRequestHandler, method, path string,
+	req []byte, urlVars map[string]string) *httptest.ResponseRecorder {
+	httpReq, err := http.NewRequest(
+		method,
+		path,
+		bytes.NewBuffer(req),
+	)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	req1 := mux.SetURLVars(httpReq, urlVars)
+
+	handler(rr, req1)
+
+	return rr
+}
+
+func getHandler(t *testing.T, op *restapi.Operation, lookup, method string) common.HTTPHandler {
+	handlers := op.GetRESTHandlers()
+	require.NotEmpty(t, handlers)
+
+	for _, h := range handlers {
+		if h.Path() == lookup && h.Method() == method {
+			return h
+		}
+	}
+
+	require.Fail(t, "unable to find handler")
+
+	return nil
+}
@@ -0,0 +1,456 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package batchwriter implements the batch writer of the mock node. It takes the place of the core batch.Writer
+// which only cuts batches on a fixed timer with the batch cutter of the current protocol version and can't be
+// extended since all of its state is unexported. The mock needs a configurable batch policy (maximum operations,
+// bytes and age), listeners for queued, cut and anchored operations, access to the pending operations, forced
+// cuts and pausing while the node state is reset. The anchoring of a batch itself follows batch.Writer.
+package batchwriter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/batch"
+	"github.com/trustbloc/sidetree-core-go/pkg/batch/cutter"
+
+	"github.com/trustbloc/sidetree-mock/pkg/opqueue"
+)
+
+var logger = logrus.New()
+
+// retryInterval is the time to wait before a batch is cut again after a batch couldn't be anchored
+const retryInterval = time.Second
+
+// OperationQueue is the queue of the operations that are waiting to be batched
+type OperationQueue interface {
+	cutter.OperationQueue
+	// OldestQueuedTime returns the time at which the operation at the head of the queue was queued
+	OldestQueuedTime() (time.Time, bool)
+	// Entries returns the operations in the queue
+	Entries() []*opqueue.Entry
+	// RemoveEntry removes the operation with the given ID from the queue
+	RemoveEntry(id uint64) (bool, error)
+}
+
+// Listener is notified when operations are queued and when batches are anchored. Listeners are invoked
+// synchronously by the batch writer so they must not block.
+type Listener interface {
+	// OperationQueued is invoked after the given operation was added to the queue of the namespace
+	OperationQueued(namespace string, op *operation.QueuedOperation)
+	// BatchAnchored is invoked after the anchor string of a batch holding the referenced operations was written
+	BatchAnchored(namespace, anchor string, ops []*operation.Reference)
+}
+
+// CutListener is a listener that is also notified when batches are cut
+type CutListener interface {
+	Listener
+	// BatchCut is invoked after the given operations were removed from the queue to be anchored in a batch
+	BatchCut(namespace string, ops []*operation.QueuedOperation, reason string)
+}
+
+// Writer cuts the queued operations of a namespace into batches and anchors the batches in the ledger. A batch is
+// cut as soon as one of the limits of the batch policy is reached:
+//
+//   - the batch holds the maximum number of operations (at most the maximum of the protocol version of the batch)
+//   - the batch holds the maximum number of bytes of operation requests (at most the maximum chunk file size of
+//     the protocol version of the batch)
+//   - the oldest operation in the batch has been queued for the maximum batch age
+//
+// With the default policy a batch is cut as soon as operations are queued.
+type Writer struct {
+	namespace     string
+	context       batch.Context
+	queue         OperationQueue
+	maxOperations uint
+	maxBytes      int
+	maxAge        time.Duration
+	listeners     []Listener
+	cutCh         chan struct{}
+	stopCh        chan struct{}
+	stopped       uint32
+	// mutex is held while a batch is cut and anchored
+	mutex sync.Mutex
+	// addMutex is held exclusively while the batch writer is paused so that no operations are added
+	addMutex sync.RWMutex
+}
+
+// Option is a batch writer option
+type Option func(w *Writer)
+
+// WithMaxOperations sets the maximum number of operations in a batch. The maximum number of operations of the
+// protocol version of the batch is used if it is lower or if the maximum isn't set.
+func WithMaxOperations(maxOperations uint) Option {
+	return func(w *Writer) {
+		w.maxOperations = maxOperations
+	}
+}
+
+// WithMaxBytes sets the maximum total size of the operation requests in a batch. The maximum chunk file size of the
+// protocol version of the batch is used if it is lower or if the maximum isn't set. A batch always holds at least one
+// operation even if the operation exceeds the maximum.
+func WithMaxBytes(maxBytes int) Option {
+	return func(w *Writer) {
+		w.maxBytes = maxBytes
+	}
+}
+
+// WithMaxAge sets the maximum time an operation waits in the queue before the batch holding it is cut
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(w *Writer) {
+		w.maxAge = maxAge
+	}
+}
+
+// WithListener adds a listener that is notified when operations are queued and when batches are anchored
+func WithListener(l Listener) Option {
+	return func(w *Writer) {
+		w.listeners = append(w.listeners, l)
+	}
+}
+
+// New returns a new batch writer for the given namespace. The operation queue of the context must record the time
+// at which the operations were queued.
+func New(namespace string, ctx batch.Context, opts ...Option) (*Writer, error) {
+	queue, ok := ctx.OperationQueue().(OperationQueue)
+	if !ok {
+		return nil, errors.New("operation queue doesn't record the time at which operations are queued")
+	}
+
+	w := &Writer{
+		namespace: namespace,
+		context:   ctx,
+		queue:     queue,
+		cutCh:     make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w, nil
+}
+
+// Start starts the routine that cuts batches
+func (w *Writer) Start() {
+	go w.listen()
+}
+
+// Stop stops the routine that cuts batches. Queued operations remain in the queue.
+func (w *Writer) Stop() {
+	if atomic.CompareAndSwapUint32(&w.stopped, 0, 1) {
+		close(w.stopCh)
+	}
+}
+
+// Pause blocks new operations and waits until the batch that is being anchored (if any) has been anchored. No
+// batches are cut and Add blocks until Resume is called.
+func (w *Writer) Pause() {
+	w.addMutex.Lock()
+	w.mutex.Lock()
+}
+
+// Resume resumes accepting operations and cutting batches after a call to Pause
+func (w *Writer) Resume() {
+	w.mutex.Unlock()
+	w.addMutex.Unlock()
+}
+
+// Add adds the given operation to the queue. The operation is anchored once its batch is cut.
+func (w *Writer) Add(op *operation.QueuedOperation, protocolVersion uint64) error {
+	w.addMutex.RLock()
+	defer w.addMutex.RUnlock()
+
+	if atomic.LoadUint32(&w.stopped) == 1 {
+		return errors.New("batch writer is stopped")
+	}
+
+	if _, err := w.queue.Add(op, protocolVersion); err != nil {
+		return fmt.Errorf("add operation to queue: %w", err)
+	}
+
+	for _, l := range w.listeners {
+		l.OperationQueued(w.namespace, op)
+	}
+
+	w.notify()
+
+	return nil
+}
+
+// Namespace returns the namespace of the batch writer
+func (w *Writer) Namespace() string {
+	return w.namespace
+}
+
+// Pending returns the operations that are waiting to be batched. Operations of a batch that is being anchored
+// aren't included.
+func (w *Writer) Pending() []*opqueue.Entry {
+	return w.queue.Entries()
+}
+
+// RemovePending removes the pending operation with the given ID so that it is never anchored. False is returned
+// if the operation isn't pending (e.g. because it has already been anchored).
+func (w *Writer) RemovePending(id uint64) (bool, error) {
+	// operations can't be removed while a batch is being cut
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.queue.RemoveEntry(id)
+}
+
+// Cut cuts and anchors all pending operations without waiting for the maximum batch age. The operations are
+// anchored in as many batches as required by the limits of the batch policy. The number of anchored operations
+// is returned.
+func (w *Writer) Cut() (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	anchored := 0
+
+	for {
+		ops, _, err := w.next()
+		if err != nil {
+			return anchored, err
+		}
+
+		if len(ops) == 0 {
+			return anchored, nil
+		}
+
+		logger.Infof("[%s] cutting batch of %d operations: forced", w.namespace, len(ops))
+
+		if err := w.cut(uint(len(ops)), ops[0].ProtocolVersion, "forced"); err != nil {
+			return anchored, fmt.Errorf("anchor batch of %d operations: %w", len(ops), err)
+		}
+
+		anchored += len(ops)
+	}
+}
+
+// notify wakes up the routine that cuts batches so that it checks the batch limits
+func (w *Writer) notify() {
+	select {
+	case w.cutCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Writer) listen() {
+	// operations that are already in the queue (e.g. after a restart) are checked immediately
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			logger.Infof("[%s] The batch writer has been stopped. Exiting.", w.namespace)
+
+			return
+		case <-w.cutCh:
+		case <-timer.C:
+		}
+
+		wait, pending := w.cutAvailable()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		if pending {
+			timer.Reset(wait)
+		}
+	}
+}
+
+// cutAvailable cuts and anchors batches until no limit of the batch policy is reached. It returns the time after
+// which the remaining operations must be checked again and whether any operations remain in the queue.
+func (w *Writer) cutAvailable() (time.Duration, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for {
+		ops, reason, err := w.next()
+		if err != nil {
+			logger.Errorf("[%s] Failed to check operation queue: %s", w.namespace, err)
+
+			return retryInterval, true
+		}
+
+		if len(ops) == 0 {
+			return 0, false
+		}
+
+		if reason == "" {
+			queuedAt, ok := w.queue.OldestQueuedTime()
+			if !ok {
+				return 0, false
+			}
+
+			age := time.Since(queuedAt)
+			if age < w.maxAge {
+				return w.maxAge - age, true
+			}
+
+			reason = "maximum batch age reached"
+		}
+
+		logger.Infof("[%s] cutting batch of %d operations: %s", w.namespace, len(ops), reason)
+
+		if err := w.cut(uint(len(ops)), ops[0].ProtocolVersion, reason); err != nil {
+			logger.Errorf("[%s] Failed to anchor batch of %d operations: %s", w.namespace, len(ops), err)
+
+			return retryInterval, true
+		}
+	}
+}
+
+// next returns the operations at the head of the queue that fit into the next batch along with the reason why
+// the batch is full. The reason is empty if the batch isn't full.
+func (w *Writer) next() (operation.QueuedOperationsAtTime, string, error) {
+	head, err := w.queue.Peek(1)
+	if err != nil {
+		return nil, "", fmt.Errorf("peek operation queue: %w", err)
+	}
+
+	if len(head) == 0 {
+		return nil, "", nil
+	}
+
+	// the batch is anchored under the protocol version of the operation at the head of the queue
+	maxOperations, maxBytes, err := w.limits(head[0].ProtocolVersion)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ops, err := w.queue.Peek(maxOperations)
+	if err != nil {
+		return nil, "", fmt.Errorf("peek operation queue: %w", err)
+	}
+
+	size := 0
+
+	for i, op := range ops {
+		switch {
+		case op.ProtocolVersion != ops[0].ProtocolVersion:
+			// operations of different protocol versions can't be anchored in the same batch
+			return ops[:i], "protocol version changed", nil
+		case i > 0 && size+len(op.OperationRequest) > maxBytes:
+			return ops[:i], "maximum number of bytes reached", nil
+		}
+
+		size += len(op.OperationRequest)
+	}
+
+	switch {
+	case size >= maxBytes:
+		return ops, "maximum number of bytes reached", nil
+	case len(ops) > 0 && uint(len(ops)) == maxOperations:
+		return ops, "maximum number of operations reached", nil
+	default:
+		return ops, "", nil
+	}
+}
+
+// limits returns the maximum number of operations and bytes of a batch which are limited by the protocol version
+// that the batch is anchored under
+func (w *Writer) limits(protocolVersion uint64) (uint, int, error) {
+	pv, err := w.context.Protocol().Get(protocolVersion)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get protocol version: %w", err)
+	}
+
+	maxOperations := pv.Protocol().MaxOperationCount
+	if w.maxOperations > 0 && w.maxOperations < maxOperations {
+		maxOperations = w.maxOperations
+	}
+
+	// the deltas of the operations must fit into a chunk file
+	maxBytes := int(pv.Protocol().MaxChunkFileSize)
+	if w.maxBytes > 0 && w.maxBytes < maxBytes {
+		maxBytes = w.maxBytes
+	}
+
+	return maxOperations, maxBytes, nil
+}
+
+// cut removes the given number of operations from the queue and anchors them. The operations are put back into
+// the queue if they can't be anchored.
+func (w *Writer) cut(count uint, protocolVersion uint64, reason string) error {
+	removed, ack, nack, err := w.queue.Remove(count)
+	if err != nil {
+		return fmt.Errorf("remove operations from queue: %w", err)
+	}
+
+	for _, l := range w.listeners {
+		if cl, ok := l.(CutListener); ok {
+			cl.BatchCut(w.namespace, removed.QueuedOperations(), reason)
+		}
+	}
+
+	anchoringInfo, err := w.anchor(removed.QueuedOperations(), protocolVersion)
+	if err != nil {
+		nack()
+
+		return err
+	}
+
+	pending := ack()
+
+	// the batch may only hold one operation per suffix so additional operations go into the next batch. They are
+	// only queued once the batch has been anchored since they are part of the removed operations otherwise.
+	for _, op := range anchoringInfo.AdditionalOperations {
+		if _, err := w.queue.Add(op, protocolVersion); err != nil {
+			logger.Warnf("[%s] Failed to add operation for suffix [%s] to the next batch: %s",
+				w.namespace, op.UniqueSuffix, err)
+
+			continue
+		}
+
+		pending++
+	}
+
+	logger.Infof("[%s] anchored batch of %d operations. Pending operations: %d", w.namespace, len(removed), pending)
+
+	for _, l := range w.listeners {
+		l.BatchAnchored(w.namespace, anchoringInfo.AnchorString, anchoringInfo.OperationReferences)
+	}
+
+	return nil
+}
+
+func (w *Writer) anchor(ops []*operation.QueuedOperation, protocolVersion uint64) (*protocol.AnchoringInfo, error) {
+	pv, err := w.context.Protocol().Get(protocolVersion)
+	if err != nil {
+		return nil, fmt.Errorf("get protocol version: %w", err)
+	}
+
+	anchoringInfo, err := pv.OperationHandler().PrepareTxnFiles(ops)
+	if err != nil {
+		return nil, fmt.Errorf("prepare batch files: %w", err)
+	}
+
+	logger.Debugf("[%s] writing anchor string: %s", w.namespace, anchoringInfo.AnchorString)
+
+	err = w.context.Anchor().WriteAnchor(anchoringInfo.AnchorString, anchoringInfo.Artifacts,
+		anchoringInfo.OperationReferences, protocolVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return anchoringInfo, nil
+}
@@ -0,0 +1,409 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package batchwriter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/batch/cutter"
+	coreopqueue "github.com/trustbloc/sidetree-core-go/pkg/batch/opqueue"
+	coremocks "github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	sidetreecontext "github.com/trustbloc/sidetree-mock/pkg/context"
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	"github.com/trustbloc/sidetree-mock/pkg/opqueue"
+)
+
+func TestWriter(t *testing.T) {
+	t.Run("default policy cuts batch right away", func(t *testing.T) {
+		w, anchorWriter, _ := newWriter(t, 10)
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("maximum number of operations", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxOperations(2), WithMaxAge(time.Hour))
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, w.Add(newOperation(i), 0))
+		}
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 2 }, time.Second, 10*time.Millisecond)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(0), 2)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(1), 2)
+		require.Equal(t, uint(1), w.queue.Len())
+	})
+
+	t.Run("maximum number of operations is limited by protocol", func(t *testing.T) {
+		w, anchorWriter, _ := newWriter(t, 1, WithMaxOperations(5), WithMaxAge(time.Hour))
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.NoError(t, w.Add(newOperation(2), 0))
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 2 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("maximum number of bytes", func(t *testing.T) {
+		// each operation request is 4 bytes
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxBytes(10), WithMaxAge(time.Hour))
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, w.Add(newOperation(i), 0))
+		}
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(0), 2)
+		require.Equal(t, uint(1), w.queue.Len())
+	})
+
+	t.Run("maximum age", func(t *testing.T) {
+		w, anchorWriter, _ := newWriter(t, 10, WithMaxAge(200*time.Millisecond))
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.NoError(t, w.Add(newOperation(2), 0))
+
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, anchorWriter.Transactions())
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+		require.Zero(t, w.queue.Len())
+	})
+
+	t.Run("maximum number of bytes is limited by protocol", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxBytes(1000), WithMaxAge(time.Hour))
+
+		p := mocks.DefaultProtocol()
+		p.MaxOperationCount = 10
+		p.MaxChunkFileSize = 4
+
+		pv, err := w.context.Protocol().Current()
+		require.NoError(t, err)
+		pv.(*coremocks.ProtocolVersion).ProtocolReturns(p)
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(0), 1)
+	})
+
+	t.Run("limits of the protocol version of the batch", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 1, WithMaxAge(time.Hour))
+
+		// the current protocol version allows more operations per batch than the version of the operations
+		p := mocks.DefaultProtocol()
+		p.GenesisTime = 10
+		p.MaxOperationCount = 10
+
+		current := &coremocks.ProtocolVersion{}
+		current.ProtocolReturns(p)
+		current.OperationHandlerReturns(handler)
+
+		pc := w.context.Protocol().(*coremocks.MockProtocolClient)
+		pc.CurrentVersion = current
+		pc.Versions = append(pc.Versions, current)
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.NoError(t, w.Add(newOperation(2), 0))
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 2 }, time.Second, 10*time.Millisecond)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(0), 1)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(1), 1)
+	})
+
+	t.Run("operations of different protocol versions are anchored in separate batches", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxOperations(2), WithMaxAge(time.Hour))
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.NoError(t, w.Add(newOperation(2), 10))
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(0), 1)
+	})
+
+	t.Run("operations stay in queue if batch can't be anchored", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10)
+		handler.PrepareTxnFilesReturns(nil, errors.New("injected error"))
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.Eventually(t, func() bool { return handler.PrepareTxnFilesCallCount() > 0 }, time.Second, 10*time.Millisecond)
+		require.Empty(t, anchorWriter.Transactions())
+		require.Equal(t, uint(1), w.queue.Len())
+	})
+
+	t.Run("additional operations are queued once the batch has been anchored", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxAge(time.Hour))
+		w.Stop()
+
+		handler.PrepareTxnFilesReturns(&protocol.AnchoringInfo{
+			AnchorString:         "1.anchor",
+			AdditionalOperations: []*operation.QueuedOperation{newOperation(2)},
+		}, nil)
+
+		anchorErr := errors.New("injected anchor error")
+		w.context = sidetreecontext.New(w.context.Protocol(), &failingAnchorWriter{
+			AnchorWriter: anchorWriter,
+			err:          anchorErr,
+		}, w.queue.(*opqueue.Queue))
+
+		_, err := w.queue.Add(newOperation(1), 0)
+		require.NoError(t, err)
+
+		_, err = w.Cut()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "injected anchor error")
+
+		// the batch is put back without the additional operation
+		entries := w.Pending()
+		require.Len(t, entries, 1)
+		require.Equal(t, "suffix1", entries[0].Operation.UniqueSuffix)
+
+		w.context = sidetreecontext.New(w.context.Protocol(), anchorWriter, w.queue.(*opqueue.Queue))
+		handler.PrepareTxnFilesReturnsOnCall(2, &protocol.AnchoringInfo{AnchorString: "1.anchor2"}, nil)
+
+		n, err := w.Cut()
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Len(t, anchorWriter.Transactions(), 2)
+		require.Equal(t, "suffix2", handler.PrepareTxnFilesArgsForCall(2)[0].UniqueSuffix)
+		require.Zero(t, w.queue.Len())
+	})
+
+	t.Run("pending operations", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxOperations(2), WithMaxAge(time.Hour))
+		require.Equal(t, mocks.DefaultNS, w.Namespace())
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, w.Add(newOperation(i), 0))
+		}
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+
+		pending := w.Pending()
+		require.Len(t, pending, 1)
+		require.Equal(t, "suffix2", pending[0].Operation.UniqueSuffix)
+
+		removed, err := w.RemovePending(pending[0].ID)
+		require.NoError(t, err)
+		require.True(t, removed)
+		require.Empty(t, w.Pending())
+
+		removed, err = w.RemovePending(pending[0].ID)
+		require.NoError(t, err)
+		require.False(t, removed)
+
+		require.Len(t, handler.PrepareTxnFilesArgsForCall(0), 2)
+	})
+
+	t.Run("cut", func(t *testing.T) {
+		w, anchorWriter, handler := newWriter(t, 10, WithMaxOperations(2), WithMaxAge(time.Hour))
+
+		// the writer is stopped so that batches are only cut on demand
+		w.Stop()
+
+		n, err := w.Cut()
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		for i := 0; i < 3; i++ {
+			_, err = w.queue.Add(newOperation(i), 0)
+			require.NoError(t, err)
+		}
+
+		n, err = w.Cut()
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Len(t, anchorWriter.Transactions(), 2)
+		require.Zero(t, w.queue.Len())
+
+		handler.PrepareTxnFilesReturns(nil, errors.New("injected error"))
+
+		_, err = w.queue.Add(newOperation(4), 0)
+		require.NoError(t, err)
+
+		_, err = w.Cut()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "injected error")
+		require.Equal(t, uint(1), w.queue.Len())
+	})
+
+	t.Run("listener", func(t *testing.T) {
+		l := &mockListener{}
+
+		w, anchorWriter, handler := newWriter(t, 10, WithListener(l))
+		handler.PrepareTxnFilesReturns(&protocol.AnchoringInfo{
+			AnchorString:        "1.anchor",
+			OperationReferences: []*operation.Reference{{UniqueSuffix: "suffix1", Type: operation.TypeCreate}},
+		}, nil)
+
+		require.NoError(t, w.Add(newOperation(1), 0))
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+		require.Eventually(t, func() bool { return len(l.getAnchored()) == 1 }, time.Second, 10*time.Millisecond)
+
+		require.Equal(t, []string{"suffix1"}, l.getQueued())
+		require.Equal(t, []string{"1.anchor:suffix1"}, l.getAnchored())
+	})
+
+	t.Run("cut listener", func(t *testing.T) {
+		l := &mockCutListener{}
+
+		w, anchorWriter, _ := newWriter(t, 10, WithListener(l), WithMaxOperations(2), WithMaxAge(time.Hour))
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, w.Add(newOperation(i), 0))
+		}
+
+		require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+
+		_, err := w.Cut()
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			"maximum number of operations reached:suffix0,suffix1",
+			"forced:suffix2",
+		}, l.getCut())
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		w, _, _ := newWriter(t, 10)
+		w.Stop()
+		w.Stop()
+
+		err := w.Add(newOperation(1), 0)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "batch writer is stopped")
+	})
+
+	t.Run("operation queue doesn't record queued time", func(t *testing.T) {
+		ctx := &memQueueContext{ServerContext: sidetreecontext.New(coremocks.NewMockProtocolClient(),
+			observer.NewAnchorWriter(mocks.DefaultNS), opqueue.New())}
+
+		_, err := New(mocks.DefaultNS, ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "operation queue doesn't record the time")
+	})
+}
+
+func newWriter(t *testing.T, protocolMaxOperations uint,
+	opts ...Option) (*Writer, *observer.AnchorWriter, *coremocks.OperationHandler) {
+	t.Helper()
+
+	handler := &coremocks.OperationHandler{}
+	handler.PrepareTxnFilesReturns(&protocol.AnchoringInfo{AnchorString: "1.anchor"}, nil)
+
+	p := mocks.DefaultProtocol()
+	p.MaxOperationCount = protocolMaxOperations
+
+	pv := &coremocks.ProtocolVersion{}
+	pv.ProtocolReturns(p)
+	pv.OperationHandlerReturns(handler)
+
+	pc := coremocks.NewMockProtocolClient()
+	pc.CurrentVersion = pv
+	pc.Versions = []*coremocks.ProtocolVersion{pv}
+
+	anchorWriter := observer.NewAnchorWriter(mocks.DefaultNS)
+
+	w, err := New(mocks.DefaultNS, sidetreecontext.New(pc, anchorWriter, opqueue.New()), opts...)
+	require.NoError(t, err)
+
+	w.Start()
+	t.Cleanup(w.Stop)
+
+	return w, anchorWriter, handler
+}
+
+func newOperation(i int) *operation.QueuedOperation {
+	return &operation.QueuedOperation{
+		UniqueSuffix:     fmt.Sprintf("suffix%d", i),
+		OperationRequest: []byte(fmt.Sprintf("op%02d", i)),
+	}
+}
+
+type mockListener struct {
+	mutex    sync.Mutex
+	queued   []string
+	anchored []string
+}
+
+func (m *mockListener) OperationQueued(namespace string, op *operation.QueuedOperation) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.queued = append(m.queued, op.UniqueSuffix)
+}
+
+func (m *mockListener) BatchAnchored(namespace, anchor string, ops []*operation.Reference) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, op := range ops {
+		m.anchored = append(m.anchored, anchor+":"+op.UniqueSuffix)
+	}
+}
+
+func (m *mockListener) getQueued() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.queued
+}
+
+func (m *mockListener) getAnchored() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.anchored
+}
+
+type mockCutListener struct {
+	mockListener
+	cut []string
+}
+
+func (m *mockCutListener) BatchCut(namespace string, ops []*operation.QueuedOperation, reason string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	suffixes := make([]string, len(ops))
+	for i, op := range ops {
+		suffixes[i] = op.UniqueSuffix
+	}
+
+	m.cut = append(m.cut, reason+":"+strings.Join(suffixes, ","))
+}
+
+func (m *mockCutListener) getCut() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.cut
+}
+
+type failingAnchorWriter struct {
+	*observer.AnchorWriter
+	err error
+}
+
+func (w *failingAnchorWriter) WriteAnchor(string, []*protocol.AnchorDocument, []*operation.Reference, uint64) error {
+	return w.err
+}
+
+type memQueueContext struct {
+	*sidetreecontext.ServerContext
+}
+
+func (c *memQueueContext) OperationQueue() cutter.OperationQueue {
+	return &coreopqueue.MemQueue{}
+}
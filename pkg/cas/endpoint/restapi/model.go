@@ -0,0 +1,43 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import "encoding/json"
+
+// FileType is the type of a Sidetree batch file.
+type FileType string
+
+const (
+	// FileTypeCoreIndex is a core index file.
+	FileTypeCoreIndex FileType = "coreIndex"
+	// FileTypeCoreProof is a core proof file.
+	FileTypeCoreProof FileType = "coreProof"
+	// FileTypeProvisionalIndex is a provisional index file.
+	FileTypeProvisionalIndex FileType = "provisionalIndex"
+	// FileTypeProvisionalProof is a provisional proof file.
+	FileTypeProvisionalProof FileType = "provisionalProof"
+	// FileTypeChunk is a chunk file.
+	FileTypeChunk FileType = "chunk"
+	// FileTypeUnknown is content that isn't a Sidetree batch file.
+	FileTypeUnknown FileType = "unknown"
+)
+
+// ErrorResponse to send error message in the response.
+type ErrorResponse struct {
+	Message string `json:"errMessage,omitempty"`
+}
+
+// DecodedContent is the decompressed content at a CAS address. Content holds the JSON content or, if the
+// content isn't JSON, the content as a string.
+type DecodedContent struct {
+	Address string `json:"address"`
+	// Size is the size of the content as stored in CAS
+	Size        int             `json:"size"`
+	Compression string          `json:"compression,omitempty"`
+	Type        FileType        `json:"type"`
+	Content     json.RawMessage `json:"content"`
+}
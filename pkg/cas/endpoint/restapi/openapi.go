@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+// genericError model
+//
+// swagger:response genericError
+type genericError struct { // nolint: unused,deadcode
+	// in: body
+	Body ErrorResponse
+}
+
+// contentReq model
+//
+// swagger:parameters contentReq
+type contentReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	Address string `json:"address"`
+}
+
+// contentResp model
+//
+// swagger:response contentResp
+type contentResp struct { // nolint: unused,deadcode
+	// in: body
+	Body []byte
+}
+
+// decodedReq model
+//
+// swagger:parameters decodedReq
+type decodedReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	Address string `json:"address"`
+}
+
+// decodedResp model
+//
+// swagger:response decodedResp
+type decodedResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *DecodedContent
+}
@@ -0,0 +1,374 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+var logger = log.New("cas-rest")
+
+// API endpoints.
+const (
+	contentEndpoint = "/cas/{address}"
+	decodedEndpoint = "/cas/{address}/decoded"
+)
+
+const addressParam = "address"
+
+const (
+	gzipContentType = "application/gzip"
+	jsonContentType = "application/json"
+)
+
+// gzipMagic are the first bytes of GZIP compressed content
+var gzipMagic = []byte{0x1f, 0x8b}
+
+type casReader interface {
+	Read(address string) ([]byte, error)
+}
+
+// ProtocolClient provides the current protocol version of a namespace
+type ProtocolClient interface {
+	Current() (protocol.Version, error)
+}
+
+// New returns CAS operations.
+func New(c *Config) *Operation {
+	return &Operation{
+		cas:       c.CAS,
+		protocols: c.Protocols,
+	}
+}
+
+// Operation defines handlers for CAS operations.
+type Operation struct {
+	cas       casReader
+	protocols []ProtocolClient
+}
+
+// Config defines configuration for CAS operations. The content is decompressed up to the largest decompressed
+// file size allowed by the current protocol versions (the default protocol if no protocol clients are set).
+type Config struct {
+	CAS       casReader
+	Protocols []ProtocolClient
+}
+
+// GetRESTHandlers get all controller API handler available for this service.
+func (o *Operation) GetRESTHandlers() []common.HTTPHandler {
+	return []common.HTTPHandler{
+		newHTTPHandler(contentEndpoint, http.MethodGet, o.contentHandler),
+		newHTTPHandler(decodedEndpoint, http.MethodGet, o.decodedHandler),
+	}
+}
+
+// contentHandler swagger:route Get /cas/{address} cas contentReq
+//
+// contentHandler returns the content at the given CAS address as it was written. The address serves as the entity
+// tag. Clients must revalidate cached content since content is removed from the CAS when the node is reset.
+//
+// Responses:
+//    default: genericError
+//        200: contentResp
+func (o *Operation) contentHandler(rw http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)[addressParam]
+
+	content, ok := o.read(rw, address)
+	if !ok {
+		return
+	}
+
+	etag := fmt.Sprintf("%q", address)
+
+	rw.Header().Set("ETag", etag)
+	rw.Header().Set("Cache-Control", "no-cache")
+
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		rw.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", contentType(content))
+	rw.WriteHeader(http.StatusOK)
+
+	if _, err := rw.Write(content); err != nil {
+		logger.Errorf("Unable to send CAS content: %s", err)
+	}
+}
+
+// decodedHandler swagger:route Get /cas/{address}/decoded cas decodedReq
+//
+// decodedHandler returns the content at the given CAS address decompressed and, if the content is a Sidetree
+// batch file, with the type of the batch file.
+//
+// Responses:
+//    default: genericError
+//        200: decodedResp
+func (o *Operation) decodedHandler(rw http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)[addressParam]
+
+	content, ok := o.read(rw, address)
+	if !ok {
+		return
+	}
+
+	resp := &DecodedContent{
+		Address: address,
+		Size:    len(content),
+	}
+
+	if bytes.HasPrefix(content, gzipMagic) {
+		maxSize, err := o.maxDecompressedSize()
+		if err != nil {
+			writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("get protocol: %s", err))
+
+			return
+		}
+
+		decompressed, err := decompress(content, maxSize)
+		if err != nil {
+			writeErrorResponse(rw, http.StatusUnprocessableEntity, fmt.Sprintf("decompress content: %s", err))
+
+			return
+		}
+
+		resp.Compression = "GZIP"
+		content = decompressed
+	}
+
+	if json.Valid(content) {
+		resp.Type = fileType(content)
+		resp.Content = content
+	} else {
+		// content that isn't JSON is returned as a string
+		resp.Type = FileTypeUnknown
+		resp.Content, _ = json.Marshal(string(content)) // nolint: errcheck
+	}
+
+	body, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("marshal content: %s", err))
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", jsonContentType)
+	rw.WriteHeader(http.StatusOK)
+
+	if _, err := rw.Write(body); err != nil {
+		logger.Errorf("Unable to send decoded CAS content: %s", err)
+	}
+}
+
+// read reads the content at the given address. An error response is written if the content can't be read.
+func (o *Operation) read(rw http.ResponseWriter, address string) ([]byte, bool) {
+	if _, err := encoder.DecodeString(address); err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid CAS address [%s]: %s", address, err))
+
+		return nil, false
+	}
+
+	content, err := o.cas.Read(address)
+	if err != nil {
+		if errors.Is(err, mocks.ErrNotFound) {
+			writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("content [%s] not found", address))
+		} else {
+			writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("read content [%s]: %s", address, err))
+		}
+
+		return nil, false
+	}
+
+	return content, true
+}
+
+// fileType returns the type of the Sidetree batch file with the given JSON content. Index files reference
+// operations by objects whereas proof files hold the signed data of the operations as strings.
+func fileType(content []byte) FileType {
+	file := &struct {
+		ProvisionalIndexFileURI string                       `json:"provisionalIndexFileUri"`
+		CoreProofFileURI        string                       `json:"coreProofFileUri"`
+		Chunks                  json.RawMessage              `json:"chunks"`
+		Deltas                  json.RawMessage              `json:"deltas"`
+		Operations              map[string][]json.RawMessage `json:"operations"`
+	}{}
+
+	if err := json.Unmarshal(content, file); err != nil {
+		return FileTypeUnknown
+	}
+
+	switch {
+	case file.Deltas != nil:
+		return FileTypeChunk
+	case file.Chunks != nil:
+		return FileTypeProvisionalIndex
+	case file.ProvisionalIndexFileURI != "" || file.CoreProofFileURI != "" || file.Operations["create"] != nil:
+		return FileTypeCoreIndex
+	}
+
+	isProof := func(ops []json.RawMessage) bool {
+		return len(ops) > 0 && strings.HasPrefix(string(bytes.TrimSpace(ops[0])), `"`)
+	}
+
+	switch {
+	case len(file.Operations["recover"]) > 0 || len(file.Operations["deactivate"]) > 0:
+		if isProof(file.Operations["recover"]) || isProof(file.Operations["deactivate"]) {
+			return FileTypeCoreProof
+		}
+
+		return FileTypeCoreIndex
+	case len(file.Operations["update"]) > 0:
+		if isProof(file.Operations["update"]) {
+			return FileTypeProvisionalProof
+		}
+
+		return FileTypeProvisionalIndex
+	default:
+		return FileTypeUnknown
+	}
+}
+
+// maxDecompressedSize returns the largest decompressed file size that is allowed by the current protocol versions
+func (o *Operation) maxDecompressedSize() (uint, error) {
+	if len(o.protocols) == 0 {
+		return maxDecompressedFileSize(mocks.DefaultProtocol()), nil
+	}
+
+	var maxSize uint
+
+	for _, pc := range o.protocols {
+		pv, err := pc.Current()
+		if err != nil {
+			return 0, err
+		}
+
+		if size := maxDecompressedFileSize(pv.Protocol()); size > maxSize {
+			maxSize = size
+		}
+	}
+
+	return maxSize, nil
+}
+
+// maxDecompressedFileSize returns the largest size of a batch file of the given protocol after decompression
+func maxDecompressedFileSize(p protocol.Protocol) uint {
+	maxSize := p.MaxCoreIndexFileSize
+
+	for _, size := range []uint{p.MaxProofFileSize, p.MaxProvisionalIndexFileSize, p.MaxChunkFileSize} {
+		if size > maxSize {
+			maxSize = size
+		}
+	}
+
+	return maxSize * p.MaxMemoryDecompressionFactor
+}
+
+// decompress decompresses the given GZIP content. An error is returned if the decompressed content exceeds
+// the given size.
+func decompress(content []byte, maxSize uint) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := r.Close(); e != nil {
+			logger.Warnf("Failed to close GZIP reader: %s", e)
+		}
+	}()
+
+	decompressed, err := ioutil.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decompressed) > int(maxSize) {
+		return nil, fmt.Errorf("decompressed content exceeds maximum size %d", maxSize)
+	}
+
+	return decompressed, nil
+}
+
+func contentType(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, gzipMagic):
+		return gzipContentType
+	case json.Valid(content):
+		return jsonContentType
+	default:
+		return http.DetectContentType(content)
+	}
+}
+
+// matchesETag returns true if the given If-None-Match header matches the entity tag
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeErrorResponse write error resp.
+func writeErrorResponse(rw http.ResponseWriter, status int, msg string) {
+	rw.Header().Set("Content-Type", jsonContentType)
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(ErrorResponse{
+		Message: msg,
+	})
+	if err != nil {
+		logger.Errorf("Unable to send error message, %s", err)
+	}
+}
+
+// newHTTPHandler returns instance of HTTPHandler which can be used to handle http requests.
+func newHTTPHandler(path, method string, handle common.HTTPRequestHandler) common.HTTPHandler {
+	return &httpHandler{path: path, method: method, handle: handle}
+}
+
+// HTTPHandler contains REST API handling details which can be used to build routers.
+// for http requests for given path.
+type httpHandler struct {
+	path   string
+	method string
+	handle common.HTTPRequestHandler
+}
+
+// Path returns http request path.
+func (h *httpHandler) Path() string {
+	return h.path
+}
+
+// Method returns http request method type.
+func (h *httpHandler) Method() string {
+	return h.method
+}
+
+// Handler returns http request handle func.
+func (h *httpHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
@@ -0,0 +1,281 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/compression"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/cas/endpoint/restapi"
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+const (
+	contentEndpoint = "/cas/{address}"
+	decodedEndpoint = "/cas/{address}/decoded"
+)
+
+func TestGetRESTHandlers(t *testing.T) {
+	c := restapi.New(&restapi.Config{})
+	require.Equal(t, 2, len(c.GetRESTHandlers()))
+}
+
+func TestContent(t *testing.T) {
+	casClient := mocks.NewMockCasClient(nil)
+
+	c := restapi.New(&restapi.Config{CAS: casClient})
+
+	handler := getHandler(t, c, contentEndpoint)
+
+	t.Run("compressed content", func(t *testing.T) {
+		content := compress(t, `{"deltas":[]}`)
+
+		address, err := casClient.Write(content)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+		require.Equal(t, `"`+address+`"`, rr.Header().Get("ETag"))
+		require.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+		require.Equal(t, content, rr.Body.Bytes())
+	})
+
+	t.Run("JSON content", func(t *testing.T) {
+		address, err := casClient.Write([]byte(`{"field":"value"}`))
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		require.Equal(t, `{"field":"value"}`, rr.Body.String())
+	})
+
+	t.Run("text content", func(t *testing.T) {
+		address, err := casClient.Write([]byte("text"))
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		address, err := casClient.Write([]byte("text"))
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address,
+			map[string]string{"If-None-Match": `"other", "` + address + `"`})
+		require.Equal(t, http.StatusNotModified, rr.Code)
+		require.Empty(t, rr.Body.Bytes())
+
+		rr = serveHTTP(t, handler.Handler(), address, map[string]string{"If-None-Match": `"other"`})
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), "invalid+address", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid CAS address [invalid+address]")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), "EiDahaOGH-liLLdDtTxEAdc8i-cfCz-WUcQdRJheMVNn3A", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "content [EiDahaOGH-liLLdDtTxEAdc8i-cfCz-WUcQdRJheMVNn3A] not found")
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{CAS: &mockCAS{err: errors.New("injected error")}})
+
+		rr := serveHTTP(t, getHandler(t, c, contentEndpoint).Handler(),
+			"EiDahaOGH-liLLdDtTxEAdc8i-cfCz-WUcQdRJheMVNn3A", nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+func TestDecoded(t *testing.T) {
+	casClient := mocks.NewMockCasClient(nil)
+
+	c := restapi.New(&restapi.Config{CAS: casClient})
+
+	handler := getHandler(t, c, decodedEndpoint)
+
+	decode := func(t *testing.T, content []byte) *restapi.DecodedContent {
+		t.Helper()
+
+		address, err := casClient.Write(content)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		decoded := &restapi.DecodedContent{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), decoded))
+		require.Equal(t, address, decoded.Address)
+		require.Equal(t, len(content), decoded.Size)
+
+		return decoded
+	}
+
+	t.Run("batch files", func(t *testing.T) {
+		for content, fileType := range map[string]restapi.FileType{
+			`{"provisionalIndexFileUri":"uri","operations":{"create":[{"suffixData":{}}]}}`:   restapi.FileTypeCoreIndex,
+			`{"coreProofFileUri":"uri","operations":{"recover":[{"didSuffix":"suffix"}]}}`:    restapi.FileTypeCoreIndex,
+			`{"operations":{"deactivate":[{"didSuffix":"suffix","revealValue":"value"}]}}`:    restapi.FileTypeCoreIndex,
+			`{"operations":{"recover":["jws"],"deactivate":["jws"]}}`:                         restapi.FileTypeCoreProof,
+			`{"chunks":[{"chunkFileUri":"uri"}],"operations":{"update":[{"didSuffix":"s"}]}}`: restapi.FileTypeProvisionalIndex,
+			`{"operations":{"update":[{"didSuffix":"suffix","revealValue":"value"}]}}`:        restapi.FileTypeProvisionalIndex,
+			`{"operations":{"update":["jws"]}}`:                                               restapi.FileTypeProvisionalProof,
+			`{"deltas":[{"patches":[]}]}`:                                                     restapi.FileTypeChunk,
+			`{"field":"value"}`:                                                               restapi.FileTypeUnknown,
+			`["value"]`:                                                                       restapi.FileTypeUnknown,
+		} {
+			decoded := decode(t, compress(t, content))
+			require.Equal(t, "GZIP", decoded.Compression)
+			require.Equal(t, fileType, decoded.Type, content)
+			require.JSONEq(t, content, string(decoded.Content))
+		}
+	})
+
+	t.Run("uncompressed content", func(t *testing.T) {
+		decoded := decode(t, []byte(`{"deltas":[]}`))
+		require.Empty(t, decoded.Compression)
+		require.Equal(t, restapi.FileTypeChunk, decoded.Type)
+	})
+
+	t.Run("text content", func(t *testing.T) {
+		decoded := decode(t, []byte("text"))
+		require.Equal(t, restapi.FileTypeUnknown, decoded.Type)
+		require.Equal(t, `"text"`, string(decoded.Content))
+	})
+
+	t.Run("invalid compressed content", func(t *testing.T) {
+		address, err := casClient.Write([]byte{0x1f, 0x8b, 0x00})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		require.Contains(t, rr.Body.String(), "decompress content")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), "EiDahaOGH-liLLdDtTxEAdc8i-cfCz-WUcQdRJheMVNn3A", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("decompressed content exceeds maximum size", func(t *testing.T) {
+		p := mocks.DefaultProtocol()
+		p.MaxCoreIndexFileSize = 10
+		p.MaxProofFileSize = 10
+		p.MaxProvisionalIndexFileSize = 10
+		p.MaxChunkFileSize = 20
+		p.MaxMemoryDecompressionFactor = 2
+
+		pc, err := mocks.NewMockProtocolClientProvider().WithProtocols(p).ForNamespace(mocks.DefaultNS)
+		require.NoError(t, err)
+
+		handler := getHandler(t, restapi.New(&restapi.Config{
+			CAS:       casClient,
+			Protocols: []restapi.ProtocolClient{pc},
+		}), decodedEndpoint)
+
+		address, err := casClient.Write(compress(t, `{"deltas":[{"patches":[]}]}`))
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		address, err = casClient.Write(compress(t, `{"deltas":[{"patches":[]},{"patches":[]}]}`))
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		require.Contains(t, rr.Body.String(), "decompressed content exceeds maximum size 40")
+	})
+
+	t.Run("protocol error", func(t *testing.T) {
+		handler := getHandler(t, restapi.New(&restapi.Config{
+			CAS:       casClient,
+			Protocols: []restapi.ProtocolClient{&mockProtocolClient{err: errors.New("injected error")}},
+		}), decodedEndpoint)
+
+		address, err := casClient.Write(compress(t, `{"deltas":[]}`))
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handler(), address, nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "injected error")
+	})
+}
+
+type mockProtocolClient struct {
+	err error
+}
+
+func (m *mockProtocolClient) Current() (protocol.Version, error) {
+	return nil, m.err
+}
+
+type mockCAS struct {
+	err error
+}
+
+func (m *mockCAS) Read(string) ([]byte, error) {
+	return nil, m.err
+}
+
+func compress(t *testing.T, content string) []byte {
+	t.Helper()
+
+	compressed, err := compression.New(compression.WithDefaultAlgorithms()).Compress("GZIP", []byte(content))
+	require.NoError(t, err)
+
+	return compressed
+}
+
+func serveHTTP(t *testing.T, handler common.HTTPRequestHandler, address string,
+	headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(http.MethodGet, "/cas/"+address, nil)
+	require.NoError(t, err)
+
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler(rr, mux.SetURLVars(httpReq, map[string]string{"address": address}))
+
+	return rr
+}
+
+func getHandler(t *testing.T, op *restapi.Operation, lookup string) common.HTTPHandler {
+	t.Helper()
+
+	for _, h := range op.GetRESTHandlers() {
+		if h.Path() == lookup {
+			return h
+		}
+	}
+
+	require.Fail(t, "unable to find handler")
+
+	return nil
+}
@@ -10,24 +10,24 @@ import (
 	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-core-go/pkg/batch"
 	"github.com/trustbloc/sidetree-core-go/pkg/batch/cutter"
-	"github.com/trustbloc/sidetree-core-go/pkg/batch/opqueue"
-	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/sidetree-mock/pkg/opqueue"
 )
 
 // New returns a new server context
-func New(pc protocol.Client) *ServerContext {
+func New(pc protocol.Client, anchorWriter batch.AnchorWriter, opQueue *opqueue.Queue) *ServerContext {
 	return &ServerContext{
 		ProtocolClient: pc,
-		AnchorWriter:   mocks.NewMockAnchorWriter(nil),
-		OpQueue:        &opqueue.MemQueue{},
+		AnchorWriter:   anchorWriter,
+		OpQueue:        opQueue,
 	}
 }
 
 // ServerContext implements batch context
 type ServerContext struct {
 	ProtocolClient protocol.Client
-	AnchorWriter   *mocks.MockAnchorWriter
-	OpQueue        *opqueue.MemQueue
+	AnchorWriter   batch.AnchorWriter
+	OpQueue        *opqueue.Queue
 }
 
 // Protocol returns the ProtocolClient
@@ -0,0 +1,12 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+// ErrorResponse to send error message in the response.
+type ErrorResponse struct {
+	Message string `json:"errMessage,omitempty"`
+}
@@ -0,0 +1,33 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+// genericError model
+//
+// swagger:response genericError
+type genericError struct { // nolint: unused,deadcode
+	// in: body
+	Body ErrorResponse
+}
+
+// eventsReq model
+//
+// swagger:parameters eventsReq
+type eventsReq struct { // nolint: unused,deadcode
+	// in: header
+	LastEventID string `json:"Last-Event-ID"`
+
+	// in: query
+	LastEventIDParam string `json:"lastEventId"`
+}
+
+// eventsResp model
+//
+// swagger:response eventsResp
+type eventsResp struct { // nolint: unused,deadcode
+	// in: body
+	Body string
+}
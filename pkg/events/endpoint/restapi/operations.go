@@ -0,0 +1,223 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/events"
+)
+
+var logger = log.New("events-rest")
+
+// API endpoints.
+const (
+	eventsEndpoint = "/events"
+)
+
+const (
+	// lastEventIDHeader is sent by clients that reconnect to the event stream
+	lastEventIDHeader = "Last-Event-ID"
+	// lastEventIDParam may be used instead of the header by clients that can't set headers (e.g. EventSource)
+	lastEventIDParam = "lastEventId"
+)
+
+const (
+	eventStreamContentType = "text/event-stream"
+	jsonContentType        = "application/json"
+)
+
+// defaultKeepAliveInterval is the interval at which comments are sent on an idle stream so that the connection
+// isn't closed by proxies
+const defaultKeepAliveInterval = 15 * time.Second
+
+type broker interface {
+	Subscribe(lastEventID *uint64) ([]*events.Event, *events.Subscription)
+}
+
+// New returns event stream operations.
+func New(c *Config) *Operation {
+	keepAliveInterval := c.KeepAliveInterval
+	if keepAliveInterval == 0 {
+		keepAliveInterval = defaultKeepAliveInterval
+	}
+
+	return &Operation{
+		broker:            c.Broker,
+		keepAliveInterval: keepAliveInterval,
+	}
+}
+
+// Operation defines handlers for the event stream.
+type Operation struct {
+	broker            broker
+	keepAliveInterval time.Duration
+}
+
+// Config defines configuration for the event stream.
+type Config struct {
+	Broker broker
+	// KeepAliveInterval is the interval at which comments are sent on an idle stream (15 seconds by default)
+	KeepAliveInterval time.Duration
+}
+
+// GetRESTHandlers get all controller API handler available for this service.
+func (o *Operation) GetRESTHandlers() []common.HTTPHandler {
+	return []common.HTTPHandler{
+		newHTTPHandler(eventsEndpoint, http.MethodGet, o.eventsHandler),
+	}
+}
+
+// eventsHandler swagger:route Get /events events eventsReq
+//
+// eventsHandler streams the events of the node as server-sent events. A client that reconnects with the
+// Last-Event-ID header (or the lastEventId query parameter) first receives the retained events following that
+// event.
+//
+// Responses:
+//    default: genericError
+//        200: eventsResp
+func (o *Operation) eventsHandler(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		writeErrorResponse(rw, http.StatusInternalServerError, "streaming is not supported")
+
+		return
+	}
+
+	lastEventID, err := getLastEventID(r)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	backlog, subscription := o.broker.Subscribe(lastEventID)
+	defer subscription.Close()
+
+	rw.Header().Set("Content-Type", eventStreamContentType)
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	// disable response buffering by nginx
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for _, e := range backlog {
+		if err := writeEvent(rw, e); err != nil {
+			logger.Debugf("Unable to send event %d: %s", e.ID, err)
+
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	ticker := time.NewTicker(o.keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-subscription.Events():
+			if !ok {
+				// the client didn't keep up - it may reconnect and resume after the last event it received
+				return
+			}
+
+			if err := writeEvent(rw, e); err != nil {
+				logger.Debugf("Unable to send event %d: %s", e.ID, err)
+
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func getLastEventID(r *http.Request) (*uint64, error) {
+	value := r.Header.Get(lastEventIDHeader)
+	if value == "" {
+		value = r.URL.Query().Get(lastEventIDParam)
+	}
+
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last event ID [%s]", value)
+	}
+
+	return &id, nil
+}
+
+// writeEvent writes the event in the server-sent events format. The data is the JSON encoded event.
+func writeEvent(rw http.ResponseWriter, e *events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	_, err = fmt.Fprintf(rw, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+
+	return err
+}
+
+// writeErrorResponse write error resp.
+func writeErrorResponse(rw http.ResponseWriter, status int, msg string) {
+	rw.Header().Set("Content-Type", jsonContentType)
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(ErrorResponse{
+		Message: msg,
+	})
+	if err != nil {
+		logger.Errorf("Unable to send error message, %s", err)
+	}
+}
+
+// newHTTPHandler returns instance of HTTPHandler which can be used to handle http requests.
+func newHTTPHandler(path, method string, handle common.HTTPRequestHandler) common.HTTPHandler {
+	return &httpHandler{path: path, method: method, handle: handle}
+}
+
+// HTTPHandler contains REST API handling details which can be used to build routers.
+// for http requests for given path.
+type httpHandler struct {
+	path   string
+	method string
+	handle common.HTTPRequestHandler
+}
+
+// Path returns http request path.
+func (h *httpHandler) Path() string {
+	return h.path
+}
+
+// Method returns http request method type.
+func (h *httpHandler) Method() string {
+	return h.method
+}
+
+// Handler returns http request handle func.
+func (h *httpHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
@@ -0,0 +1,209 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-mock/pkg/events"
+	"github.com/trustbloc/sidetree-mock/pkg/events/endpoint/restapi"
+)
+
+func TestGetRESTHandlers(t *testing.T) {
+	handlers := restapi.New(&restapi.Config{Broker: events.New()}).GetRESTHandlers()
+	require.Len(t, handlers, 1)
+	require.Equal(t, "/events", handlers[0].Path())
+	require.Equal(t, http.MethodGet, handlers[0].Method())
+}
+
+func TestEvents(t *testing.T) {
+	t.Run("stream", func(t *testing.T) {
+		broker := events.New()
+		server := newServer(t, broker, time.Minute)
+
+		stream := connect(t, server.URL, nil)
+
+		broker.Publish(events.BatchCut, "data1")
+		broker.Publish(events.AnchorWritten, "data2")
+
+		e := stream.next(t)
+		require.Equal(t, "1", e.id)
+		require.Equal(t, string(events.BatchCut), e.event)
+		require.Equal(t, uint64(1), e.data.ID)
+		require.Equal(t, events.BatchCut, e.data.Type)
+		require.Equal(t, "data1", e.data.Data)
+
+		e = stream.next(t)
+		require.Equal(t, "2", e.id)
+		require.Equal(t, string(events.AnchorWritten), e.event)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		broker := events.New()
+		server := newServer(t, broker, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			broker.Publish(events.BatchCut, i)
+		}
+
+		stream := connect(t, server.URL, http.Header{"Last-Event-ID": []string{"1"}})
+		require.Equal(t, "2", stream.next(t).id)
+		require.Equal(t, "3", stream.next(t).id)
+
+		broker.Publish(events.BatchCut, 3)
+		require.Equal(t, "4", stream.next(t).id)
+
+		stream = connect(t, server.URL+"?lastEventId=3", nil)
+		require.Equal(t, "4", stream.next(t).id)
+	})
+
+	t.Run("keep-alive", func(t *testing.T) {
+		server := newServer(t, events.New(), 10*time.Millisecond)
+
+		stream := connect(t, server.URL, nil)
+
+		line, err := stream.reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, ": keep-alive\n", line)
+	})
+
+	t.Run("invalid last event ID", func(t *testing.T) {
+		server := newServer(t, events.New(), time.Minute)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		req.Header.Set("Last-Event-ID", "abc")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		errResp := &restapi.ErrorResponse{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(errResp))
+		require.Equal(t, "invalid last event ID [abc]", errResp.Message)
+	})
+
+	t.Run("client disconnects", func(t *testing.T) {
+		broker := events.New()
+
+		done := make(chan struct{})
+
+		handler := restapi.New(&restapi.Config{Broker: broker}).GetRESTHandlers()[0].Handler()
+
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			handler(rw, r)
+			close(done)
+		}))
+		t.Cleanup(server.Close)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		cancel()
+		require.NoError(t, resp.Body.Close())
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "handler didn't return after the client disconnected")
+		}
+	})
+}
+
+type streamEvent struct {
+	id    string
+	event string
+	data  *events.Event
+}
+
+type stream struct {
+	reader *bufio.Reader
+}
+
+func newServer(t *testing.T, broker *events.Broker, keepAliveInterval time.Duration) *httptest.Server {
+	t.Helper()
+
+	handler := restapi.New(&restapi.Config{
+		Broker:            broker,
+		KeepAliveInterval: keepAliveInterval,
+	}).GetRESTHandlers()[0].Handler()
+
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func connect(t *testing.T, url string, header http.Header) *stream {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, resp.Body.Close())
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	return &stream{reader: bufio.NewReader(resp.Body)}
+}
+
+// next reads the next event from the stream.
+func (s *stream) next(t *testing.T) *streamEvent {
+	t.Helper()
+
+	e := &streamEvent{}
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			require.NotNil(t, e.data)
+
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = &events.Event{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), e.data))
+		}
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+
+	"github.com/trustbloc/sidetree-mock/pkg/opinfo"
+)
+
+var logger = logrus.New()
+
+// Type is the type of a node event.
+type Type string
+
+const (
+	// OperationAccepted is published when an operation was accepted and queued by the batch writer.
+	OperationAccepted Type = "operation.accepted"
+	// BatchCut is published when the batch writer removed operations from the queue to anchor them in a batch.
+	BatchCut Type = "batch.cut"
+	// AnchorWritten is published when the batch writer wrote the anchor of a batch.
+	AnchorWritten Type = "anchor.written"
+	// TransactionProcessed is published when the observer processed a ledger transaction.
+	TransactionProcessed Type = "transaction.processed"
+	// DIDStateChanged is published when operations on a DID were processed into the operation store, i.e. when
+	// the resolved state of the DID changed.
+	DIDStateChanged Type = "did.changed"
+)
+
+const (
+	defaultHistorySize = 1000
+
+	// subscriberBufferSize is the number of events that may wait for a subscriber. A subscriber that falls further
+	// behind is closed; it may resume from its last event ID (as long as the event is still in the history).
+	subscriberBufferSize = 100
+)
+
+// Event is an event of the node. Events are numbered in sequence starting at 1 when the node starts.
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type Type        `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Operation is an operation in the data of an event. The transaction fields are only set for operations that
+// were processed into the operation store.
+type Operation = opinfo.Operation
+
+// OperationData is the data of an operation.accepted event.
+type OperationData struct {
+	Namespace string `json:"namespace"`
+	*Operation
+}
+
+// BatchData is the data of batch.cut and anchor.written events. Reason (why the batch was cut) is only set for
+// batch.cut events and Anchor is only set for anchor.written events.
+type BatchData struct {
+	Namespace  string       `json:"namespace"`
+	Reason     string       `json:"reason,omitempty"`
+	Anchor     string       `json:"anchor,omitempty"`
+	Operations []*Operation `json:"operations"`
+}
+
+// TransactionData is the data of a transaction.processed event.
+type TransactionData struct {
+	Namespace          string `json:"namespace"`
+	TransactionNumber  uint64 `json:"transactionNumber"`
+	TransactionTime    uint64 `json:"transactionTime"`
+	AnchorString       string `json:"anchorString"`
+	CanonicalReference string `json:"canonicalReference,omitempty"`
+	ProtocolVersion    uint64 `json:"protocolVersion"`
+}
+
+// DIDData is the data of a did.changed event. Operations are the operations that changed the state of the DID.
+type DIDData struct {
+	Namespace    string       `json:"namespace"`
+	DID          string       `json:"did"`
+	UniqueSuffix string       `json:"uniqueSuffix"`
+	Operations   []*Operation `json:"operations"`
+}
+
+// Broker publishes the events of the node to subscribers. The most recent events are retained so that a subscriber
+// that reconnects may resume after the last event it received.
+type Broker struct {
+	mutex       sync.Mutex
+	history     []*Event
+	historySize int
+	lastID      uint64
+	subscribers map[*Subscription]struct{}
+	ledger      opinfo.Ledger
+}
+
+// Option is a broker option
+type Option func(b *Broker)
+
+// WithHistorySize sets the number of events that are retained for subscribers that resume
+func WithHistorySize(size int) Option {
+	return func(b *Broker) {
+		b.historySize = size
+	}
+}
+
+// WithLedger sets the ledger from which the canonical references of the transactions of processed operations
+// are taken
+func WithLedger(ledger opinfo.Ledger) Option {
+	return func(b *Broker) {
+		b.ledger = ledger
+	}
+}
+
+// New returns a new event broker
+func New(opts ...Option) *Broker {
+	b := &Broker{
+		historySize: defaultHistorySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Subscription receives the events published after it was created. The events channel is closed when the
+// subscription is closed, either by the subscriber or by the broker if the subscriber doesn't keep up.
+type Subscription struct {
+	broker *Broker
+	events chan *Event
+	closed bool
+}
+
+// Events returns the channel on which the events are received
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Close closes the subscription
+func (s *Subscription) Close() {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+
+	s.broker.unsubscribe(s)
+}
+
+// Subscribe returns a subscription to the events that are published from now on. If the ID of the last event that
+// the subscriber received is given then the retained events following that event are returned as well (all
+// retained events are returned if the ID is newer than the last published event, e.g. because the node restarted).
+func (b *Broker) Subscribe(lastEventID *uint64) ([]*Event, *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	s := &Subscription{broker: b, events: make(chan *Event, subscriberBufferSize)}
+	b.subscribers[s] = struct{}{}
+
+	if lastEventID == nil {
+		return nil, s
+	}
+
+	var backlog []*Event
+
+	for _, e := range b.history {
+		if e.ID > *lastEventID || *lastEventID > b.lastID {
+			backlog = append(backlog, e)
+		}
+	}
+
+	return backlog, s
+}
+
+// Publish publishes an event with the given type and data
+func (b *Broker) Publish(t Type, data interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastID++
+
+	e := &Event{ID: b.lastID, Type: t, Time: time.Now().UTC(), Data: data}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for s := range b.subscribers {
+		select {
+		case s.events <- e:
+		default:
+			logger.Warnf("Subscriber doesn't keep up with events - closing subscription at event %d", e.ID)
+
+			b.unsubscribe(s)
+		}
+	}
+}
+
+// OperationQueued publishes an operation.accepted event (implements batchwriter.Listener)
+func (b *Broker) OperationQueued(namespace string, op *operation.QueuedOperation) {
+	b.Publish(OperationAccepted, &OperationData{
+		Namespace: namespace,
+		Operation: opinfo.NewQueued(namespace, op),
+	})
+}
+
+// BatchCut publishes a batch.cut event (implements batchwriter.CutListener)
+func (b *Broker) BatchCut(namespace string, queuedOps []*operation.QueuedOperation, reason string) {
+	ops := make([]*Operation, len(queuedOps))
+
+	for i, op := range queuedOps {
+		ops[i] = opinfo.NewQueued(namespace, op)
+	}
+
+	b.Publish(BatchCut, &BatchData{Namespace: namespace, Reason: reason, Operations: ops})
+}
+
+// BatchAnchored publishes an anchor.written event (implements batchwriter.Listener)
+func (b *Broker) BatchAnchored(namespace, anchor string, refs []*operation.Reference) {
+	ops := make([]*Operation, len(refs))
+
+	for i, ref := range refs {
+		ops[i] = opinfo.NewAnchored(namespace, ref)
+	}
+
+	b.Publish(AnchorWritten, &BatchData{Namespace: namespace, Anchor: anchor, Operations: ops})
+}
+
+// TransactionProcessed publishes a transaction.processed event for a transaction that was processed by the observer
+func (b *Broker) TransactionProcessed(sidetreeTxn txn.SidetreeTxn) {
+	b.Publish(TransactionProcessed, &TransactionData{
+		Namespace:          sidetreeTxn.Namespace,
+		TransactionNumber:  sidetreeTxn.TransactionNumber,
+		TransactionTime:    sidetreeTxn.TransactionTime,
+		AnchorString:       sidetreeTxn.AnchorString,
+		CanonicalReference: sidetreeTxn.CanonicalReference,
+		ProtocolVersion:    sidetreeTxn.ProtocolVersion,
+	})
+}
+
+// OperationsProcessed publishes a did.changed event for each DID of the operations that were processed into the
+// operation store of the namespace
+func (b *Broker) OperationsProcessed(namespace string, anchoredOps []*operation.AnchoredOperation) {
+	var (
+		suffixes []string
+		opsByDID = make(map[string][]*Operation)
+	)
+
+	for _, op := range anchoredOps {
+		if _, ok := opsByDID[op.UniqueSuffix]; !ok {
+			suffixes = append(suffixes, op.UniqueSuffix)
+		}
+
+		opsByDID[op.UniqueSuffix] = append(opsByDID[op.UniqueSuffix], opinfo.NewProcessed(namespace, op, b.ledger))
+	}
+
+	for _, suffix := range suffixes {
+		b.Publish(DIDStateChanged, &DIDData{
+			Namespace:    namespace,
+			DID:          opinfo.DID(namespace, suffix),
+			UniqueSuffix: suffix,
+			Operations:   opsByDID[suffix],
+		})
+	}
+}
+
+// unsubscribe removes the subscription and closes its channel. The broker must be locked.
+func (b *Broker) unsubscribe(s *Subscription) {
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+
+	delete(b.subscribers, s)
+	close(s.events)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+)
+
+const namespace = "did:sidetree"
+
+func TestBroker(t *testing.T) {
+	t.Run("publish", func(t *testing.T) {
+		b := New(WithLedger(mockLedger{2: "anchor"}))
+
+		backlog, s := b.Subscribe(nil)
+		require.Empty(t, backlog)
+
+		defer s.Close()
+
+		b.OperationQueued(namespace, &operation.QueuedOperation{
+			UniqueSuffix:     "suffix1",
+			OperationRequest: []byte(`{"type":"create"}`),
+		})
+		b.BatchCut(namespace, []*operation.QueuedOperation{{UniqueSuffix: "suffix1"}}, "forced")
+		b.BatchAnchored(namespace, "1.anchor", []*operation.Reference{
+			{UniqueSuffix: "suffix1", Type: operation.TypeCreate},
+		})
+		b.TransactionProcessed(txn.SidetreeTxn{Namespace: namespace, TransactionNumber: 2, TransactionTime: 5,
+			AnchorString: "1.anchor", CanonicalReference: "anchor"})
+		b.OperationsProcessed(namespace, []*operation.AnchoredOperation{
+			{UniqueSuffix: "suffix1", Type: operation.TypeCreate, TransactionNumber: 2},
+			{UniqueSuffix: "suffix2", Type: operation.TypeCreate, TransactionNumber: 2},
+			{UniqueSuffix: "suffix1", Type: operation.TypeUpdate, TransactionNumber: 2},
+		})
+
+		received := receive(t, s, 6)
+
+		for i, eventType := range []Type{OperationAccepted, BatchCut, AnchorWritten, TransactionProcessed,
+			DIDStateChanged, DIDStateChanged} {
+			require.Equal(t, uint64(i+1), received[i].ID)
+			require.Equal(t, eventType, received[i].Type)
+			require.False(t, received[i].Time.IsZero())
+		}
+
+		accepted := received[0].Data.(*OperationData)
+		require.Equal(t, namespace+":suffix1", accepted.DID)
+		require.Equal(t, operation.TypeCreate, accepted.Type)
+
+		cut := received[1].Data.(*BatchData)
+		require.Equal(t, "forced", cut.Reason)
+		require.Len(t, cut.Operations, 1)
+		require.Empty(t, cut.Operations[0].Type)
+
+		require.Equal(t, "1.anchor", received[2].Data.(*BatchData).Anchor)
+
+		processed := received[3].Data.(*TransactionData)
+		require.Equal(t, uint64(2), processed.TransactionNumber)
+		require.Equal(t, uint64(5), processed.TransactionTime)
+		require.Equal(t, "anchor", processed.CanonicalReference)
+
+		changed := received[4].Data.(*DIDData)
+		require.Equal(t, "suffix1", changed.UniqueSuffix)
+		require.Len(t, changed.Operations, 2)
+		require.Equal(t, operation.TypeUpdate, changed.Operations[1].Type)
+		require.Equal(t, uint64(2), *changed.Operations[1].TransactionNumber)
+		require.Equal(t, "anchor", changed.Operations[1].CanonicalReference)
+		require.Equal(t, "suffix2", received[5].Data.(*DIDData).UniqueSuffix)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		b := New(WithHistorySize(3))
+
+		for i := 0; i < 5; i++ {
+			b.Publish(BatchCut, i)
+		}
+
+		lastEventID := uint64(3)
+
+		backlog, s := b.Subscribe(&lastEventID)
+		require.Len(t, backlog, 2)
+		require.Equal(t, uint64(4), backlog[0].ID)
+		require.Equal(t, uint64(5), backlog[1].ID)
+
+		b.Publish(BatchCut, 5)
+		require.Equal(t, uint64(6), receive(t, s, 1)[0].ID)
+
+		s.Close()
+		s.Close()
+
+		_, ok := <-s.Events()
+		require.False(t, ok)
+
+		// events that are no longer retained can't be replayed
+		lastEventID = 1
+
+		backlog, _ = b.Subscribe(&lastEventID)
+		require.Len(t, backlog, 3)
+		require.Equal(t, uint64(4), backlog[0].ID)
+
+		// all retained events are replayed if the last event ID is unknown (e.g. after a restart)
+		lastEventID = 100
+
+		backlog, _ = b.Subscribe(&lastEventID)
+		require.Len(t, backlog, 3)
+
+		lastEventID = 6
+
+		backlog, _ = b.Subscribe(&lastEventID)
+		require.Empty(t, backlog)
+	})
+
+	t.Run("slow subscriber", func(t *testing.T) {
+		b := New()
+
+		_, slow := b.Subscribe(nil)
+		_, fast := b.Subscribe(nil)
+
+		for i := 0; i < subscriberBufferSize+1; i++ {
+			b.Publish(BatchCut, i)
+
+			<-fast.Events()
+		}
+
+		require.Len(t, receive(t, slow, subscriberBufferSize), subscriberBufferSize)
+
+		_, ok := <-slow.Events()
+		require.False(t, ok)
+
+		b.Publish(BatchCut, 0)
+
+		e, ok := <-fast.Events()
+		require.True(t, ok)
+		require.Equal(t, uint64(subscriberBufferSize+2), e.ID)
+	})
+}
+
+func receive(t *testing.T, s *Subscription, n int) []*Event {
+	t.Helper()
+
+	var received []*Event
+
+	for i := 0; i < n; i++ {
+		e, ok := <-s.Events()
+		require.True(t, ok)
+
+		received = append(received, e)
+	}
+
+	return received
+}
+
+// mockLedger maps transaction numbers to canonical references
+type mockLedger map[uint64]string
+
+func (m mockLedger) Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn) {
+	number := uint64(sinceTransactionNumber + 1)
+
+	ref, ok := m[number]
+	if !ok {
+		return false, nil
+	}
+
+	return false, &txn.SidetreeTxn{TransactionNumber: number, CanonicalReference: ref}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fileutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// WriteFileAtomic writes content to a temporary file in the same directory as path and renames it to path
+// once the content has been synced, so that a crash never leaves a partially written file behind.
+func WriteFileAtomic(path string, content []byte) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".tmp-"+name)
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Sync()
+	}
+
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+
+	if err != nil {
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadYAMLAsJSON reads the YAML (or JSON) file at the given path and returns its content as JSON so that it can be
+// unmarshalled using the JSON tags of the target type. YAML is a superset of JSON so both formats are supported.
+func ReadYAMLAsJSON(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse file [%s]: %w", path, err)
+	}
+
+	doc, err = toJSONCompatible(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parse file [%s]: %w", path, err)
+	}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parse file [%s]: %w", path, err)
+	}
+
+	return jsonData, nil
+}
+
+// toJSONCompatible converts the maps in a YAML document (which may have non-string keys) to JSON objects
+func toJSONCompatible(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+
+		for k, e := range value {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported key [%v]", k)
+			}
+
+			converted, err := toJSONCompatible(e)
+			if err != nil {
+				return nil, err
+			}
+
+			m[key] = converted
+		}
+
+		return m, nil
+	case []interface{}:
+		for i, e := range value {
+			converted, err := toJSONCompatible(e)
+			if err != nil {
+				return nil, err
+			}
+
+			value[i] = converted
+		}
+
+		return value, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package history
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/processor"
+)
+
+var (
+	// ErrVersionNotFound is returned if the DID has no version with the requested version ID
+	ErrVersionNotFound = errors.New("version does not exist")
+	// ErrInvalidVersionTime is returned if the requested version time isn't an RFC3339 time
+	ErrInvalidVersionTime = errors.New("invalid version time")
+	// ErrInvalidVersion is returned if both a version ID and a version time are requested
+	ErrInvalidVersion = errors.New("version ID and version time must not both be set")
+)
+
+// Ledger holds the transactions that anchored the operations
+type Ledger interface {
+	Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn)
+	Transactions() []*txn.SidetreeTxn
+}
+
+// Processor resolves the resolution model of a DID at its latest version or at the version requested with
+// document.WithVersionID or document.WithVersionTime.
+//
+// The version ID of a document is the canonical reference of the transaction that anchored the last operation
+// applied to the document. It is taken from the ledger rather than from the anchored operations so that the
+// operations remain unpublished in the sense of the core processor (i.e. the DIDs don't include the canonical
+// reference). The version time is compared with the transaction time of the operations, which is the block height
+// of the mock ledger.
+type Processor struct {
+	namespace string
+	store     processor.OperationStoreClient
+	pc        protocol.Client
+	ledger    Ledger
+}
+
+// New returns a new processor for the operations of the given namespace
+func New(namespace string, store processor.OperationStoreClient, pc protocol.Client, ledger Ledger) *Processor {
+	return &Processor{
+		namespace: namespace,
+		store:     store,
+		pc:        pc,
+		ledger:    ledger,
+	}
+}
+
+// Resolve resolves the resolution model of the DID with the given unique suffix. ErrVersionNotFound is returned if
+// the requested version ID isn't a version of the DID.
+func (p *Processor) Resolve(uniqueSuffix string, opts ...document.ResolutionOption) (*protocol.ResolutionModel, error) {
+	resOpts, err := document.GetResolutionOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	include, err := p.filter(resOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	store := p.store
+	if include != nil {
+		store = &filteredStore{store: p.store, include: include}
+	}
+
+	// the version options are applied by the filtered store
+	rm, err := processor.New(p.namespace, store, p.pc).Resolve(uniqueSuffix,
+		document.WithAdditionalOperations(resOpts.AdditionalOperations))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rm.PublishedOperations) > 0 {
+		rm.VersionID = p.canonicalReference(rm.LastOperationTransactionNumber)
+	}
+
+	if resOpts.VersionID != "" && rm.VersionID != resOpts.VersionID {
+		return nil, fmt.Errorf("%w: [%s] is not a version of [%s]", ErrVersionNotFound, resOpts.VersionID,
+			uniqueSuffix)
+	}
+
+	return rm, nil
+}
+
+// filter returns the function that selects the operations up to the requested version. Nil is returned if no
+// version is requested.
+func (p *Processor) filter(opts document.ResolutionOptions) (func(op *operation.AnchoredOperation) bool, error) {
+	switch {
+	case opts.VersionID != "" && opts.VersionTime != "":
+		return nil, ErrInvalidVersion
+	case opts.VersionID != "":
+		t, ok := p.transaction(opts.VersionID)
+		if !ok {
+			return nil, fmt.Errorf("%w: no transaction with canonical reference [%s]", ErrVersionNotFound,
+				opts.VersionID)
+		}
+
+		return func(op *operation.AnchoredOperation) bool {
+			return op.TransactionNumber <= t.TransactionNumber
+		}, nil
+	case opts.VersionTime != "":
+		vt, err := time.Parse(time.RFC3339, opts.VersionTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w [%s]: %s", ErrInvalidVersionTime, opts.VersionTime, err)
+		}
+
+		// the transaction time is the block height which is reported as seconds since the Unix epoch
+		return func(op *operation.AnchoredOperation) bool {
+			return vt.Unix() >= 0 && op.TransactionTime <= uint64(vt.Unix())
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// transaction returns the transaction of the namespace with the given canonical reference
+func (p *Processor) transaction(canonicalReference string) (*txn.SidetreeTxn, bool) {
+	for _, t := range p.ledger.Transactions() {
+		if t.Namespace == p.namespace && t.CanonicalReference == canonicalReference {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// canonicalReference returns the canonical reference of the transaction with the given number
+func (p *Processor) canonicalReference(transactionNumber uint64) string {
+	_, t := p.ledger.Read(int(transactionNumber) - 1)
+	if t == nil {
+		return ""
+	}
+
+	return t.CanonicalReference
+}
+
+// filteredStore returns the operations of the underlying store that are selected by the include function
+type filteredStore struct {
+	store   processor.OperationStoreClient
+	include func(op *operation.AnchoredOperation) bool
+}
+
+func (s *filteredStore) Get(uniqueSuffix string) ([]*operation.AnchoredOperation, error) {
+	ops, err := s.store.Get(uniqueSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []*operation.AnchoredOperation
+
+	for _, op := range ops {
+		if s.include(op) {
+			filtered = append(filtered, op)
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package history
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+	"github.com/trustbloc/sidetree-core-go/pkg/patch"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/ecsigner"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
+
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+const (
+	namespace = "did:sidetree"
+	sha2_256  = 18
+)
+
+func TestProcessor_Resolve(t *testing.T) {
+	p, uniqueSuffix := newProcessor(t)
+
+	t.Run("latest version", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, "ref2", rm.VersionID)
+		require.Len(t, rm.PublishedOperations, 2)
+	})
+
+	t.Run("version ID", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionID("ref1"))
+		require.NoError(t, err)
+		require.Equal(t, "ref1", rm.VersionID)
+		require.Len(t, rm.PublishedOperations, 1)
+	})
+
+	t.Run("version ID of a transaction that doesn't change the DID", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionID("ref3"))
+		require.ErrorIs(t, err, ErrVersionNotFound)
+		require.Nil(t, rm)
+	})
+
+	t.Run("version ID of another namespace", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionID("other"))
+		require.ErrorIs(t, err, ErrVersionNotFound)
+		require.Nil(t, rm)
+	})
+
+	t.Run("unknown version ID", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionID("unknown"))
+		require.ErrorIs(t, err, ErrVersionNotFound)
+		require.Nil(t, rm)
+	})
+
+	t.Run("version time", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionTime("1970-01-01T00:00:07Z"))
+		require.NoError(t, err)
+		require.Equal(t, "ref1", rm.VersionID)
+
+		rm, err = p.Resolve(uniqueSuffix, document.WithVersionTime("2021-01-01T00:00:00Z"))
+		require.NoError(t, err)
+		require.Equal(t, "ref2", rm.VersionID)
+	})
+
+	t.Run("version time before the DID was created", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionTime("1970-01-01T00:00:01Z"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+		require.Nil(t, rm)
+	})
+
+	t.Run("invalid version time", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionTime("yesterday"))
+		require.ErrorIs(t, err, ErrInvalidVersionTime)
+		require.Nil(t, rm)
+	})
+
+	t.Run("version ID and version time", func(t *testing.T) {
+		rm, err := p.Resolve(uniqueSuffix, document.WithVersionID("ref1"),
+			document.WithVersionTime("1970-01-01T00:00:07Z"))
+		require.ErrorIs(t, err, ErrInvalidVersion)
+		require.Nil(t, rm)
+	})
+}
+
+// newProcessor returns a processor for a DID that is created in transaction 1 (ref1, block 5) and updated in
+// transaction 2 (ref2, block 10)
+func newProcessor(t *testing.T) (*Processor, string) {
+	t.Helper()
+
+	store := mocks.NewMockOperationStore()
+
+	pc, err := mocks.NewMockProtocolClientProvider().WithOpStore(store).WithOpStoreClient(store).
+		ForNamespace(namespace)
+	require.NoError(t, err)
+
+	updateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	create, updateJWK := newCreateRequest(t, updateKey)
+
+	pv, err := pc.Current()
+	require.NoError(t, err)
+
+	op, err := pv.OperationParser().Parse(namespace, create)
+	require.NoError(t, err)
+
+	update := newUpdateRequest(t, op.UniqueSuffix, updateKey, updateJWK)
+
+	require.NoError(t, store.Put([]*operation.AnchoredOperation{
+		{
+			Type: operation.TypeCreate, UniqueSuffix: op.UniqueSuffix, OperationRequest: create,
+			TransactionNumber: 1, TransactionTime: 5,
+		},
+		{
+			Type: operation.TypeUpdate, UniqueSuffix: op.UniqueSuffix, OperationRequest: update,
+			TransactionNumber: 2, TransactionTime: 10,
+		},
+	}))
+
+	l := ledger{
+		{Namespace: namespace, TransactionNumber: 1, TransactionTime: 5, CanonicalReference: "ref1"},
+		{Namespace: namespace, TransactionNumber: 2, TransactionTime: 10, CanonicalReference: "ref2"},
+		{Namespace: namespace, TransactionNumber: 3, TransactionTime: 15, CanonicalReference: "ref3"},
+		{Namespace: "did:other", TransactionNumber: 4, TransactionTime: 20, CanonicalReference: "other"},
+	}
+
+	return New(namespace, store, pc, l), op.UniqueSuffix
+}
+
+func newCreateRequest(t *testing.T, updateKey *ecdsa.PrivateKey) ([]byte, *jws.JWK) {
+	t.Helper()
+
+	updateJWK, err := pubkey.GetPublicKeyJWK(&updateKey.PublicKey)
+	require.NoError(t, err)
+
+	updateCommitment, err := commitment.GetCommitment(updateJWK, sha2_256)
+	require.NoError(t, err)
+
+	recoveryCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x", Y: "y"}, sha2_256)
+	require.NoError(t, err)
+
+	request, err := client.NewCreateRequest(&client.CreateRequestInfo{
+		OpaqueDocument:     validDoc,
+		RecoveryCommitment: recoveryCommitment,
+		UpdateCommitment:   updateCommitment,
+		MultihashCode:      sha2_256,
+	})
+	require.NoError(t, err)
+
+	return request, updateJWK
+}
+
+func newUpdateRequest(t *testing.T, uniqueSuffix string, updateKey *ecdsa.PrivateKey, updateJWK *jws.JWK) []byte {
+	t.Helper()
+
+	revealValue, err := commitment.GetRevealValue(updateJWK, sha2_256)
+	require.NoError(t, err)
+
+	nextKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	nextJWK, err := pubkey.GetPublicKeyJWK(&nextKey.PublicKey)
+	require.NoError(t, err)
+
+	nextCommitment, err := commitment.GetCommitment(nextJWK, sha2_256)
+	require.NoError(t, err)
+
+	p, err := patch.NewAddServiceEndpointsPatch(
+		`[{"id":"svc1","type":"type","serviceEndpoint":"https://example.com"}]`)
+	require.NoError(t, err)
+
+	request, err := client.NewUpdateRequest(&client.UpdateRequestInfo{
+		DidSuffix:        uniqueSuffix,
+		Patches:          []patch.Patch{p},
+		UpdateCommitment: nextCommitment,
+		UpdateKey:        updateJWK,
+		MultihashCode:    sha2_256,
+		Signer:           ecsigner.New(updateKey, "ES256", ""),
+		RevealValue:      revealValue,
+	})
+	require.NoError(t, err)
+
+	return request
+}
+
+// ledger holds the transactions in order of transaction number starting with 1
+type ledger []*txn.SidetreeTxn
+
+func (l ledger) Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn) {
+	if sinceTransactionNumber < 0 || sinceTransactionNumber >= len(l) {
+		return false, nil
+	}
+
+	return false, l[sinceTransactionNumber]
+}
+
+func (l ledger) Transactions() []*txn.SidetreeTxn {
+	return l
+}
+
+const validDoc = `{
+	"publicKey": [{
+		"id": "key-1",
+		"purposes": ["authentication"],
+		"type": "JsonWebKey2020",
+		"publicKeyJwk": {
+			"kty": "EC",
+			"crv": "P-256K",
+			"x": "PUymIqdtF_qxaAqPABSw-C-owT1KYYQbsMKFM-L9fJA",
+			"y": "nM84jDHCMOTGTh_ZdHq4dBBdo4Z5PkEOW9jA8z8IsGc"
+		}
+	}]
+}`
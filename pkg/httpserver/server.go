@@ -30,17 +30,30 @@ type Server struct {
 	keyFile    string
 }
 
+// tokenProtectedHandler is implemented by handlers that are protected by their own bearer token
+// instead of the token of the server
+type tokenProtectedHandler interface {
+	Token() string
+}
+
 // New returns a new HTTP server
 func New(url, certFile, keyFile, token string, handlers ...common.HTTPHandler) *Server {
 	router := mux.NewRouter()
 
-	if token != "" {
-		router.Use(authorizationMiddleware(token))
-	}
-
 	for _, handler := range handlers {
 		logger.Infof("Registering handler for [%s]", handler.Path())
-		router.HandleFunc(handler.Path(), handler.Handler()).Methods(handler.Method())
+
+		handlerToken := token
+		if h, ok := handler.(tokenProtectedHandler); ok {
+			handlerToken = h.Token()
+		}
+
+		var handle http.Handler = http.HandlerFunc(handler.Handler())
+		if handlerToken != "" {
+			handle = authorizationMiddleware(handlerToken)(handle)
+		}
+
+		router.Handle(handler.Path(), handle).Methods(handler.Method())
 	}
 
 	handler := cors.New(
@@ -43,6 +43,8 @@ const (
 	sha2_256        = 18
 	sampleNamespace = "sample:sidetree"
 	samplePath      = "/sample"
+	protectedPath   = "/protected"
+	protectedToken  = "tk2"
 )
 
 var (
@@ -66,6 +68,7 @@ func TestServer_Start(t *testing.T) {
 		diddochandler.NewResolveHandler(baseResolvePath, didDocHandler, &coremocks.MetricsProvider{}),
 		newSampleUpdateHandler(sampleDocHandler, pc),
 		newSampleResolveHandler(sampleDocHandler),
+		&protectedHandler{},
 	)
 	require.NoError(t, s.Start())
 	require.Error(t, s.Start())
@@ -133,6 +136,15 @@ func TestServer_Start(t *testing.T) {
 		require.NoError(t, json.Unmarshal(resp, &result))
 		require.Equal(t, sampleID, result.Document["id"])
 	})
+	t.Run("Handler with own token", func(t *testing.T) {
+		_, err := httpGet(t, clientURL+protectedPath, authorizationHdr)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Unauthorised")
+
+		resp, err := httpGet(t, clientURL+protectedPath, "Bearer "+protectedToken)
+		require.NoError(t, err)
+		require.Equal(t, "protected", string(resp))
+	})
 	t.Run("Stop", func(t *testing.T) {
 		require.NoError(t, s.Stop(context.Background()))
 		require.Error(t, s.Stop(context.Background()))
@@ -264,6 +276,30 @@ func (h *sampleResolveHandler) Handler() common.HTTPRequestHandler {
 	return h.Resolve
 }
 
+type protectedHandler struct{}
+
+// Path returns the context path
+func (h *protectedHandler) Path() string {
+	return protectedPath
+}
+
+// Method returns the HTTP method
+func (h *protectedHandler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the handler
+func (h *protectedHandler) Handler() common.HTTPRequestHandler {
+	return func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Write([]byte("protected")) // nolint:errcheck
+	}
+}
+
+// Token returns the token that protects the handler
+func (h *protectedHandler) Token() string {
+	return protectedToken
+}
+
 func getCreateRequest() ([]byte, error) {
 	updateKey := &jws.JWK{
 		Crv: "crv",
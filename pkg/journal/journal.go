@@ -0,0 +1,209 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+const recordDelimiter = '\n'
+
+// Journal is an append-only log of JSON records stored one record per line. A record is considered written
+// only after its terminating newline has been synced to disk, so a partially written record (e.g. due to a crash)
+// is discarded and truncated the next time the journal is opened.
+type Journal struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	size  int64
+}
+
+// Open opens (or creates) the journal at the given path and invokes replay for each complete record
+// in the order in which the records were appended.
+func Open(path string, replay func(record []byte) error) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open journal [%s]: %w", path, err)
+	}
+
+	size, err := readRecords(file, replay)
+	if err != nil {
+		file.Close() //nolint:errcheck,gosec
+
+		return nil, fmt.Errorf("replay journal [%s]: %w", path, err)
+	}
+
+	j := &Journal{path: filepath.Clean(path), file: file}
+
+	if err := j.truncate(size); err != nil {
+		file.Close() //nolint:errcheck,gosec
+
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Append marshals the given value and appends it to the journal as a single record.
+func (j *Journal) Append(v interface{}) error {
+	record, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal journal record: %w", err)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	_, err = j.file.Write(append(record, recordDelimiter))
+	if err == nil {
+		err = j.file.Sync()
+	}
+
+	if err != nil {
+		// Drop whatever part of the record made it to disk so that the journal remains consistent.
+		if e := j.truncate(j.size); e != nil {
+			return fmt.Errorf("append journal record: %s; rollback failed: %w", err, e)
+		}
+
+		return fmt.Errorf("append journal record: %w", err)
+	}
+
+	j.size += int64(len(record)) + 1
+
+	return nil
+}
+
+// Reset discards all records in the journal.
+func (j *Journal) Reset() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.truncate(0)
+}
+
+// Replace replaces all records in the journal with the given records. The records are written to a temporary file
+// that is renamed to the journal once it has been synced, so the journal holds either the previous or the new records
+// if the replacement fails or the node crashes.
+func (j *Journal) Replace(records ...interface{}) error {
+	var content bytes.Buffer
+
+	for _, v := range records {
+		record, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("marshal journal record: %w", err)
+		}
+
+		content.Write(record)
+		content.WriteByte(recordDelimiter)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), ".tmp-"+filepath.Base(j.path))
+	if err != nil {
+		return fmt.Errorf("create temporary journal: %w", err)
+	}
+
+	if _, err = tmp.Write(content.Bytes()); err == nil {
+		err = tmp.Sync()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.path)
+	}
+
+	if err != nil {
+		tmp.Close()           //nolint:errcheck,gosec
+		os.Remove(tmp.Name()) //nolint:errcheck,gosec
+
+		return fmt.Errorf("replace journal: %w", err)
+	}
+
+	// records are appended to the new file, which is positioned after the records that were written
+	if err := j.file.Close(); err != nil {
+		logger.Warnf("Failed to close replaced journal [%s]: %s", j.path, err)
+	}
+
+	j.file = tmp
+	j.size = int64(content.Len())
+
+	return nil
+}
+
+// Close closes the journal.
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.file.Close()
+}
+
+func (j *Journal) truncate(size int64) error {
+	if err := j.file.Truncate(size); err != nil {
+		return fmt.Errorf("truncate journal: %w", err)
+	}
+
+	if _, err := j.file.Seek(size, io.SeekStart); err != nil {
+		return fmt.Errorf("seek journal: %w", err)
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+
+	j.size = size
+
+	return nil
+}
+
+// readRecords invokes replay for each complete record in the file and returns the size of the valid portion
+// of the file. A trailing record that is missing its delimiter is ignored.
+func readRecords(file *os.File, replay func(record []byte) error) (int64, error) {
+	reader := bufio.NewReader(file)
+
+	var size int64
+
+	for {
+		line, err := reader.ReadBytes(recordDelimiter)
+		if err == io.EOF {
+			if len(line) > 0 {
+				logger.Warnf("Discarding incomplete journal record of %d bytes at offset %d", len(line), size)
+			}
+
+			return size, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		if record := bytes.TrimSpace(line); len(record) > 0 {
+			if err := replay(record); err != nil {
+				return 0, fmt.Errorf("record at offset %d: %w", size, err)
+			}
+		}
+
+		size += int64(len(line))
+	}
+}
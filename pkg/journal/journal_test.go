@@ -0,0 +1,128 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	Value string `json:"value"`
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "journal.log")
+
+	t.Run("append and replay", func(t *testing.T) {
+		j, err := Open(path, func([]byte) error { return nil })
+		require.NoError(t, err)
+
+		require.NoError(t, j.Append(&record{Value: "one"}))
+		require.NoError(t, j.Append(&record{Value: "two"}))
+		require.NoError(t, j.Close())
+
+		require.Equal(t, []string{"one", "two"}, replay(t, path))
+	})
+
+	t.Run("incomplete record is discarded", func(t *testing.T) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"value":"thr`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		j, err := Open(path, func([]byte) error { return nil })
+		require.NoError(t, err)
+		require.NoError(t, j.Append(&record{Value: "three"}))
+		require.NoError(t, j.Close())
+
+		require.Equal(t, []string{"one", "two", "three"}, replay(t, path))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		j, err := Open(path, func([]byte) error { return nil })
+		require.NoError(t, err)
+		require.NoError(t, j.Reset())
+		require.NoError(t, j.Append(&record{Value: "four"}))
+		require.NoError(t, j.Close())
+
+		require.Equal(t, []string{"four"}, replay(t, path))
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		j, err := Open(path, func([]byte) error { return nil })
+		require.NoError(t, err)
+		require.NoError(t, j.Replace(&record{Value: "five"}, &record{Value: "six"}))
+		require.NoError(t, j.Append(&record{Value: "seven"}))
+		require.NoError(t, j.Close())
+
+		require.Equal(t, []string{"five", "six", "seven"}, replay(t, path))
+
+		files, err := ioutil.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	})
+
+	t.Run("failed replace keeps records", func(t *testing.T) {
+		j, err := Open(path, func([]byte) error { return nil })
+		require.NoError(t, err)
+
+		err = j.Replace(&record{Value: "eight"}, make(chan int))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "marshal journal record")
+
+		require.NoError(t, j.Append(&record{Value: "eight"}))
+		require.NoError(t, j.Close())
+
+		require.Equal(t, []string{"five", "six", "seven", "eight"}, replay(t, path))
+	})
+
+	t.Run("replay error", func(t *testing.T) {
+		_, err := Open(path, func([]byte) error { return errors.New("injected error") })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "injected error")
+	})
+
+	t.Run("corrupt record", func(t *testing.T) {
+		corruptPath := filepath.Join(t.TempDir(), "corrupt.log")
+		require.NoError(t, ioutil.WriteFile(corruptPath, []byte("{\n{\"value\":\"one\"}\n"), 0o600))
+
+		_, err := Open(corruptPath, func(data []byte) error {
+			return json.Unmarshal(data, &record{})
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "record at offset 0")
+	})
+}
+
+func replay(t *testing.T, path string) []string {
+	t.Helper()
+
+	var values []string
+
+	j, err := Open(path, func(data []byte) error {
+		r := &record{}
+		if err := json.Unmarshal(data, r); err != nil {
+			return err
+		}
+
+		values = append(values, r.Value)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, j.Close())
+
+	return values
+}
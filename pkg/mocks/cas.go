@@ -7,32 +7,80 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
-	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-core-go/pkg/hashing"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/sidetree-mock/pkg/fileutil"
 )
 
-// MockCasClient mocks CAS for running server in test mode. It has extra functionality to detect
-// writing of the batch file to CAS. In this case it adds operations directly into operation store.
-// This is a shortcut for running server in test mode (in the absence of observer component)
+// sha2_256 is the multihash algorithm used to calculate CAS addresses
+const sha2_256 = 18
+
+// ErrNotFound is returned by Read if there is no content at the given address
+var ErrNotFound = errors.New("not found")
+
+// MockCasClient mocks CAS for running server in test mode. Content is kept in memory in CAS unless the client
+// was created with NewFileCasClient, in which case content is stored on the file system under its address.
 type MockCasClient struct {
-	CAS *mocks.MockCasClient
+	sync.RWMutex
+	CAS       *mocks.MockCasClient
+	addresses map[string]struct{}
+	dir       string
 }
 
 // NewMockCasClient creates mock cas client;
 func NewMockCasClient(err error) *MockCasClient {
-	return &MockCasClient{CAS: mocks.NewMockCasClient(nil)}
+	return &MockCasClient{CAS: mocks.NewMockCasClient(nil), addresses: make(map[string]struct{})}
+}
+
+// NewFileCasClient creates a cas client that stores each piece of content in a file named after
+// its address in the given directory.
+func NewFileCasClient(dir string) (*MockCasClient, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create CAS directory: %w", err)
+	}
+
+	return &MockCasClient{dir: dir}, nil
 }
 
 // Write writes the given content to CAS.
 // returns the SHA256 hash in base64url encoding which represents the address of the content.
 func (m *MockCasClient) Write(content []byte) (string, error) {
-
-	address, err := m.CAS.Write(content)
+	hash, err := hashing.ComputeMultihash(sha2_256, content)
 	if err != nil {
 		return "", err
 	}
 
+	address := encoder.EncodeToString(hash)
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.dir != "" {
+		err = m.writeFile(address, content)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if _, err = m.CAS.Write(content); err != nil {
+			return "", err
+		}
+
+		m.addresses[address] = struct{}{}
+	}
+
 	log.Debugf("added content with address[%s]", address)
 
 	return address, nil
@@ -41,5 +89,119 @@ func (m *MockCasClient) Write(content []byte) (string, error) {
 // Read reads the content of the given address in CAS.
 // returns the content of the given address.
 func (m *MockCasClient) Read(address string) ([]byte, error) {
-	return m.CAS.Read(address)
+	// decode address to verify hashes (this also ensures that the address is safe to use as a file name)
+	decoded, err := encoder.DecodeString(address)
+	if err != nil {
+		return nil, err
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	var value []byte
+
+	if m.dir != "" {
+		value, err = ioutil.ReadFile(filepath.Join(m.dir, address)) //nolint:gosec
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if _, ok := m.addresses[address]; !ok {
+			return nil, ErrNotFound
+		}
+
+		value, err = m.CAS.Read(address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	valueHash, err := hashing.ComputeMultihash(sha2_256, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(valueHash, decoded) {
+		return nil, fmt.Errorf("hashes don't match")
+	}
+
+	return value, nil
+}
+
+// Addresses returns the addresses of all content in CAS
+func (m *MockCasClient) Addresses() ([]string, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var addresses []string
+
+	if m.dir == "" {
+		for address := range m.addresses {
+			addresses = append(addresses, address)
+		}
+	} else {
+		files, err := ioutil.ReadDir(m.dir)
+		if err != nil {
+			return nil, fmt.Errorf("read CAS directory: %w", err)
+		}
+
+		for _, f := range files {
+			if f.Mode().IsRegular() && !strings.HasPrefix(f.Name(), ".") {
+				addresses = append(addresses, f.Name())
+			}
+		}
+	}
+
+	sort.Strings(addresses)
+
+	return addresses, nil
+}
+
+// Reset removes all content from CAS
+func (m *MockCasClient) Reset() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.dir == "" {
+		m.CAS = mocks.NewMockCasClient(nil)
+		m.addresses = make(map[string]struct{})
+
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		return fmt.Errorf("read CAS directory: %w", err)
+	}
+
+	for _, f := range files {
+		if f.Mode().IsRegular() {
+			if err := os.Remove(filepath.Join(m.dir, f.Name())); err != nil {
+				return fmt.Errorf("remove CAS content: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *MockCasClient) writeFile(address string, content []byte) error {
+	path := filepath.Join(m.dir, address)
+
+	// content is addressed by its hash so it only has to be written if the file doesn't exist or doesn't
+	// hold the content of its address (e.g. it was corrupted or truncated)
+	existing, err := ioutil.ReadFile(path) //nolint:gosec
+	if err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+
+	if err := fileutil.WriteFileAtomic(path, content); err != nil {
+		return fmt.Errorf("write CAS content: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,139 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mocks
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCasClient(t *testing.T) {
+	t.Run("content is read after reopening", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "cas")
+
+		c, err := NewFileCasClient(dir)
+		require.NoError(t, err)
+
+		address1, err := c.Write([]byte("content1"))
+		require.NoError(t, err)
+
+		address2, err := c.Write([]byte("content2"))
+		require.NoError(t, err)
+
+		// writing the same content again doesn't change anything
+		address, err := c.Write([]byte("content1"))
+		require.NoError(t, err)
+		require.Equal(t, address1, address)
+
+		c, err = NewFileCasClient(dir)
+		require.NoError(t, err)
+
+		content, err := c.Read(address1)
+		require.NoError(t, err)
+		require.Equal(t, []byte("content1"), content)
+
+		content, err = c.Read(address2)
+		require.NoError(t, err)
+		require.Equal(t, []byte("content2"), content)
+
+		addresses, err := c.Addresses()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{address1, address2}, addresses)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		c, err := NewFileCasClient(t.TempDir())
+		require.NoError(t, err)
+
+		address, err := NewMockCasClient(nil).Write([]byte("content"))
+		require.NoError(t, err)
+
+		_, err = c.Read(address)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("truncated content is rewritten", func(t *testing.T) {
+		dir := t.TempDir()
+
+		c, err := NewFileCasClient(dir)
+		require.NoError(t, err)
+
+		address, err := c.Write([]byte("content"))
+		require.NoError(t, err)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, address), []byte("cont"), 0o600))
+
+		_, err = c.Read(address)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "hashes don't match")
+
+		_, err = c.Write([]byte("content"))
+		require.NoError(t, err)
+
+		content, err := c.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, []byte("content"), content)
+	})
+
+	t.Run("temporary files are not content", func(t *testing.T) {
+		dir := t.TempDir()
+
+		c, err := NewFileCasClient(dir)
+		require.NoError(t, err)
+
+		address, err := c.Write([]byte("content"))
+		require.NoError(t, err)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".tmp-"+address+"123"), []byte("cont"), 0o600))
+
+		addresses, err := c.Addresses()
+		require.NoError(t, err)
+		require.Equal(t, []string{address}, addresses)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		dir := t.TempDir()
+
+		c, err := NewFileCasClient(dir)
+		require.NoError(t, err)
+
+		address, err := c.Write([]byte("content"))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Reset())
+
+		_, err = c.Read(address)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		addresses, err := c.Addresses()
+		require.NoError(t, err)
+		require.Empty(t, addresses)
+	})
+}
+
+func TestMockCasClient(t *testing.T) {
+	c := NewMockCasClient(nil)
+
+	address, err := c.Write([]byte("content"))
+	require.NoError(t, err)
+
+	content, err := c.CAS.Read(address)
+	require.NoError(t, err)
+	require.Equal(t, []byte("content"), content)
+
+	addresses, err := c.Addresses()
+	require.NoError(t, err)
+	require.Equal(t, []string{address}, addresses)
+
+	require.NoError(t, c.Reset())
+
+	_, err = c.Read(address)
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -7,12 +7,18 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/observer"
+
+	"github.com/trustbloc/sidetree-mock/pkg/journal"
 )
 
 // MockOpStoreProvider is a mock operation store provider
@@ -33,7 +39,15 @@ func (m *MockOpStoreProvider) ForNamespace(string) (observer.OperationStore, err
 // MockOperationStore is a mock operation store
 type MockOperationStore struct {
 	sync.RWMutex
-	operations map[string][]*operation.AnchoredOperation
+	operations  map[string][]*operation.AnchoredOperation
+	journal     *journal.Journal
+	subscribers []func(ops []*operation.AnchoredOperation)
+}
+
+// opStoreRecord is the journal record written for each call to Put (Operations) and Rollback (RollbackFrom)
+type opStoreRecord struct {
+	Operations   []*operation.AnchoredOperation `json:"operations,omitempty"`
+	RollbackFrom *uint64                        `json:"rollbackFrom,omitempty"`
 }
 
 // NewMockOperationStore returns a new mock operation store
@@ -41,17 +55,77 @@ func NewMockOperationStore() *MockOperationStore {
 	return &MockOperationStore{operations: make(map[string][]*operation.AnchoredOperation)}
 }
 
-// Put stores the given operations
-func (m *MockOperationStore) Put(ops []*operation.AnchoredOperation) error {
+// NewFileOperationStore returns an operation store that persists operations to an append-only
+// journal at the given path. Operations already in the journal are loaded into the store.
+func NewFileOperationStore(path string) (*MockOperationStore, error) {
+	m := NewMockOperationStore()
+
+	j, err := journal.Open(path, func(data []byte) error {
+		record := &opStoreRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return err
+		}
+
+		if record.RollbackFrom != nil {
+			m.rollback(*record.RollbackFrom)
+		}
+
+		m.add(record.Operations)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open operation store: %w", err)
+	}
+
+	m.journal = j
+
+	return m, nil
+}
+
+// Subscribe registers a function that is invoked with the operations that were added to the store by Put.
+// Operations that were already stored are not passed to the subscriber.
+func (m *MockOperationStore) Subscribe(subscriber func(ops []*operation.AnchoredOperation)) {
 	m.Lock()
 	defer m.Unlock()
 
+	m.subscribers = append(m.subscribers, subscriber)
+}
+
+// Put stores the given operations. Only the operations that weren't stored yet are journalled.
+func (m *MockOperationStore) Put(ops []*operation.AnchoredOperation) error {
+	m.Lock()
+
+	added := m.newOperations(ops)
+
+	if m.journal != nil && len(added) > 0 {
+		if err := m.journal.Append(&opStoreRecord{Operations: added}); err != nil {
+			m.Unlock()
+
+			return err
+		}
+	}
+
 	for _, op := range ops {
 		fmt.Printf("Putting operation type[%s], suffix[%s], txtime[%d], txnum[%d], pg[%d], buffer: %s\n", op.Type, op.UniqueSuffix, op.TransactionTime, op.TransactionNumber, op.ProtocolVersion, string(op.OperationRequest))
-		m.operations[op.UniqueSuffix] = append(m.operations[op.UniqueSuffix], op)
 	}
 
-	fmt.Printf("Have operations: %+v\n", m.operations)
+	m.add(added)
+
+	for _, op := range added {
+		log.Debugf("added operation type[%s], suffix[%s], txnum[%d]", op.Type, op.UniqueSuffix, op.TransactionNumber)
+	}
+
+	subscribers := m.subscribers
+
+	m.Unlock()
+
+	if len(added) > 0 {
+		for _, subscriber := range subscribers {
+			subscriber(added)
+		}
+	}
+
 	return nil
 }
 
@@ -67,3 +141,145 @@ func (m *MockOperationStore) Get(suffix string) ([]*operation.AnchoredOperation,
 
 	return ops, nil
 }
+
+// Suffixes returns the unique suffixes of all DIDs in the store in ascending order
+func (m *MockOperationStore) Suffixes() []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.suffixes()
+}
+
+// Operations returns all operations in the store, grouped by unique suffix in the order in which they were stored
+func (m *MockOperationStore) Operations() []*operation.AnchoredOperation {
+	m.RLock()
+	defer m.RUnlock()
+
+	var ops []*operation.AnchoredOperation
+	for _, suffix := range m.suffixes() {
+		ops = append(ops, m.operations[suffix]...)
+	}
+
+	return ops
+}
+
+// Restore replaces the contents of the store with the given operations
+func (m *MockOperationStore) Restore(ops []*operation.AnchoredOperation) error {
+	m.Lock()
+	defer m.Unlock()
+
+	// the journal is replaced before the operations in memory so that both hold the same operations if the
+	// journal can't be replaced
+	if m.journal != nil {
+		var records []interface{}
+		if len(ops) > 0 {
+			records = append(records, &opStoreRecord{Operations: ops})
+		}
+
+		if err := m.journal.Replace(records...); err != nil {
+			return err
+		}
+	}
+
+	m.operations = make(map[string][]*operation.AnchoredOperation)
+	m.add(ops)
+
+	return nil
+}
+
+// Rollback removes all operations that were anchored in the transaction with the given number or in any later
+// transaction (e.g. because the transactions were orphaned by a ledger reorg)
+func (m *MockOperationStore) Rollback(transactionNumber uint64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.journal != nil {
+		if err := m.journal.Append(&opStoreRecord{RollbackFrom: &transactionNumber}); err != nil {
+			return err
+		}
+	}
+
+	m.rollback(transactionNumber)
+
+	return nil
+}
+
+// Close closes the journal of a store that was created with NewFileOperationStore
+func (m *MockOperationStore) Close() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.journal == nil {
+		return nil
+	}
+
+	return m.journal.Close()
+}
+
+func (m *MockOperationStore) rollback(transactionNumber uint64) {
+	for suffix, ops := range m.operations {
+		var remaining []*operation.AnchoredOperation
+
+		for _, op := range ops {
+			if op.TransactionNumber < transactionNumber {
+				remaining = append(remaining, op)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(m.operations, suffix)
+		} else {
+			m.operations[suffix] = remaining
+		}
+	}
+}
+
+// add adds the given operations to the store and returns the operations that were added. Operations that were
+// already stored are ignored.
+func (m *MockOperationStore) add(ops []*operation.AnchoredOperation) []*operation.AnchoredOperation {
+	added := m.newOperations(ops)
+
+	for _, op := range added {
+		m.operations[op.UniqueSuffix] = append(m.operations[op.UniqueSuffix], op)
+	}
+
+	return added
+}
+
+// newOperations returns the given operations that aren't stored yet. Operations that were already stored (e.g. when
+// the observer re-processes a transaction after a restart) and duplicates within the given operations are ignored.
+func (m *MockOperationStore) newOperations(ops []*operation.AnchoredOperation) []*operation.AnchoredOperation {
+	var added []*operation.AnchoredOperation
+
+	for _, op := range ops {
+		if containsOperation(m.operations[op.UniqueSuffix], op) || containsOperation(added, op) {
+			continue
+		}
+
+		added = append(added, op)
+	}
+
+	return added
+}
+
+func (m *MockOperationStore) suffixes() []string {
+	suffixes := make([]string, 0, len(m.operations))
+	for suffix := range m.operations {
+		suffixes = append(suffixes, suffix)
+	}
+
+	sort.Strings(suffixes)
+
+	return suffixes
+}
+
+func containsOperation(ops []*operation.AnchoredOperation, op *operation.AnchoredOperation) bool {
+	for _, existing := range ops {
+		if existing.UniqueSuffix == op.UniqueSuffix && existing.TransactionNumber == op.TransactionNumber &&
+			existing.Type == op.Type && bytes.Equal(existing.OperationRequest, op.OperationRequest) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mocks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+)
+
+func TestFileOperationStore(t *testing.T) {
+	t.Run("operations are replayed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "operations")
+
+		s, err := NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{
+			newAnchoredOperation("suffix1", operation.TypeCreate, 1),
+			newAnchoredOperation("suffix2", operation.TypeCreate, 1),
+		}))
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{
+			newAnchoredOperation("suffix1", operation.TypeUpdate, 2),
+			newAnchoredOperation("suffix2", operation.TypeUpdate, 3),
+		}))
+		require.NoError(t, s.Rollback(3))
+		require.NoError(t, s.Close())
+
+		s, err = NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, s.Close()) }()
+
+		require.Equal(t, []string{"suffix1", "suffix2"}, s.Suffixes())
+
+		ops, err := s.Get("suffix1")
+		require.NoError(t, err)
+		require.Len(t, ops, 2)
+		require.Equal(t, operation.TypeCreate, ops[0].Type)
+		require.Equal(t, operation.TypeUpdate, ops[1].Type)
+		require.Equal(t, []byte("suffix1"), ops[1].OperationRequest)
+		require.Equal(t, uint64(2), ops[1].TransactionNumber)
+
+		// the update of suffix2 was rolled back
+		ops, err = s.Get("suffix2")
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, operation.TypeCreate, ops[0].Type)
+	})
+
+	t.Run("operations that are already stored aren't journalled", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "operations")
+
+		s, err := NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		ops := []*operation.AnchoredOperation{newAnchoredOperation("suffix1", operation.TypeCreate, 1)}
+
+		require.NoError(t, s.Put(ops))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+
+		// the observer re-processes the transaction after a restart
+		require.NoError(t, s.Put(ops))
+
+		info2, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, info.Size(), info2.Size())
+
+		// only the new operation is journalled
+		require.NoError(t, s.Put(append(ops, newAnchoredOperation("suffix2", operation.TypeCreate, 2))))
+		require.NoError(t, s.Close())
+
+		data, err := ioutil.ReadFile(path) //nolint:gosec
+		require.NoError(t, err)
+		require.Equal(t, 1, strings.Count(string(data), `"uniqueSuffix":"suffix1"`))
+		require.Equal(t, 1, strings.Count(string(data), `"uniqueSuffix":"suffix2"`))
+	})
+
+	t.Run("restored operations are replayed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "operations")
+
+		s, err := NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{newAnchoredOperation("suffix1", operation.TypeCreate, 1)}))
+		require.NoError(t, s.Restore([]*operation.AnchoredOperation{
+			newAnchoredOperation("suffix2", operation.TypeCreate, 1),
+		}))
+		require.NoError(t, s.Close())
+
+		s, err = NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, s.Close()) }()
+
+		require.Equal(t, []string{"suffix2"}, s.Suffixes())
+	})
+
+	t.Run("torn trailing record is discarded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "operations")
+
+		s, err := NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{newAnchoredOperation("suffix1", operation.TypeCreate, 1)}))
+		require.NoError(t, s.Close())
+
+		// the node stopped while the next record was being written
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"operations":[{"type":"create","uniqueSuffix":"suff`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		s, err = NewFileOperationStore(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{"suffix1"}, s.Suffixes())
+
+		// records appended after the torn record are replayed
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{newAnchoredOperation("suffix2", operation.TypeCreate, 2)}))
+		require.NoError(t, s.Close())
+
+		s, err = NewFileOperationStore(path)
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, s.Close()) }()
+
+		require.Equal(t, []string{"suffix1", "suffix2"}, s.Suffixes())
+	})
+
+	t.Run("invalid journal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "operations")
+		require.NoError(t, ioutil.WriteFile(path, []byte("invalid\n"), 0o600))
+
+		_, err := NewFileOperationStore(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open operation store")
+	})
+}
+
+func newAnchoredOperation(suffix string, opType operation.Type, txnNumber uint64) *operation.AnchoredOperation {
+	return &operation.AnchoredOperation{
+		Type:              opType,
+		UniqueSuffix:      suffix,
+		OperationRequest:  []byte(suffix),
+		TransactionNumber: txnNumber,
+	}
+}
@@ -8,6 +8,7 @@ package mocks
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/trustbloc/sidetree-core-go/pkg/api/cas"
@@ -32,13 +33,18 @@ const maxBatchFileSize = 20000
 
 // MockProtocolClient mocks protocol for testing purposes.
 type MockProtocolClient struct {
-	currentVersion *mocks.ProtocolVersion
-	versions       []*mocks.ProtocolVersion
+	versions   []*mocks.ProtocolVersion
+	ledgerTime func() uint64
 }
 
-// Current mocks getting last protocol version
+// Current mocks getting the protocol version that is in effect at the current ledger time.
+// The last protocol version is returned if the ledger time is not known.
 func (m *MockProtocolClient) Current() (protocol.Version, error) {
-	return m.currentVersion, nil
+	if m.ledgerTime == nil {
+		return m.versions[len(m.versions)-1], nil
+	}
+
+	return m.Get(m.ledgerTime())
 }
 
 // Get mocks getting protocol version based on blockchain(transaction) time
@@ -62,6 +68,31 @@ func NewMockProtocolClientProvider() *MockProtocolClientProvider {
 		opStore:       opStore,
 		opStoreClient: opStore,
 		casClient:     casClient,
+		protocols:     []protocol.Protocol{DefaultProtocol()},
+	}
+}
+
+// DefaultProtocol returns the protocol parameters that are used if no protocol versions are configured
+func DefaultProtocol() protocol.Protocol {
+	//nolint:gomnd
+	return protocol.Protocol{
+		GenesisTime:                  0,
+		MultihashAlgorithms:          []uint{18},
+		MaxOperationCount:            100,  // the batch policy of the batch writer decides when a batch is cut
+		MaxOperationSize:             2500, // has to be bigger than max delta + max proof + small number for type
+		MaxOperationHashLength:       100,
+		MaxDeltaSize:                 1800, // interop tests pass for 1000, our test is about 1100 since we have multiple public keys/services
+		MaxCasURILength:              100,
+		CompressionAlgorithm:         "GZIP",
+		MaxChunkFileSize:             maxBatchFileSize,
+		MaxProvisionalIndexFileSize:  maxBatchFileSize,
+		MaxCoreIndexFileSize:         maxBatchFileSize,
+		MaxProofFileSize:             maxBatchFileSize,
+		Patches:                      []string{"replace", "add-public-keys", "remove-public-keys", "add-services", "remove-services", "ietf-json-patch", "add-also-known-as", "remove-also-known-as"},
+		SignatureAlgorithms:          []string{"EdDSA", "ES256", "ES256K"},
+		KeyAlgorithms:                []string{"Ed25519", "P-256", "secp256k1"},
+		NonceSize:                    16,
+		MaxMemoryDecompressionFactor: 3,
 	}
 }
 
@@ -74,6 +105,8 @@ type MockProtocolClientProvider struct {
 	casClient     cas.Client
 	methodCtx     []string
 	baseEnabled   bool
+	protocols     []protocol.Protocol
+	ledgerTime    func() uint64
 }
 
 // WithOpStoreClient sets the operation store client
@@ -111,6 +144,21 @@ func (m *MockProtocolClientProvider) WithBase(enabled bool) *MockProtocolClientP
 	return m
 }
 
+// WithProtocols sets the protocol versions. Each protocol version takes effect at its genesis time.
+func (m *MockProtocolClientProvider) WithProtocols(protocols ...protocol.Protocol) *MockProtocolClientProvider {
+	m.protocols = protocols
+
+	return m
+}
+
+// WithLedgerTime sets the function that returns the current ledger time. The current protocol version
+// is selected based on the ledger time.
+func (m *MockProtocolClientProvider) WithLedgerTime(ledgerTime func() uint64) *MockProtocolClientProvider {
+	m.ledgerTime = ledgerTime
+
+	return m
+}
+
 // ForNamespace will return protocol client for that namespace
 func (m *MockProtocolClientProvider) ForNamespace(namespace string) (protocol.Client, error) {
 	m.mutex.Lock()
@@ -118,41 +166,52 @@ func (m *MockProtocolClientProvider) ForNamespace(namespace string) (protocol.Cl
 
 	pc, ok := m.clients[namespace]
 	if !ok {
-		pc = m.create()
+		var err error
+
+		pc, err = m.create()
+		if err != nil {
+			return nil, fmt.Errorf("create protocol client for namespace [%s]: %w", namespace, err)
+		}
+
 		m.clients[namespace] = pc
 	}
 
 	return pc, nil
 }
 
-func (m *MockProtocolClientProvider) create() *MockProtocolClient {
-	//nolint:gomnd
-	latest := protocol.Protocol{
-		GenesisTime:                  0,
-		MultihashAlgorithms:          []uint{18},
-		MaxOperationCount:            1,    // one operation per batch - batch gets cut right away
-		MaxOperationSize:             2500, // has to be bigger than max delta + max proof + small number for type
-		MaxOperationHashLength:       100,
-		MaxDeltaSize:                 1800, // interop tests pass for 1000, our test is about 1100 since we have multiple public keys/services
-		MaxCasURILength:              100,
-		CompressionAlgorithm:         "GZIP",
-		MaxChunkFileSize:             maxBatchFileSize,
-		MaxProvisionalIndexFileSize:  maxBatchFileSize,
-		MaxCoreIndexFileSize:         maxBatchFileSize,
-		MaxProofFileSize:             maxBatchFileSize,
-		Patches:                      []string{"replace", "add-public-keys", "remove-public-keys", "add-services", "remove-services", "ietf-json-patch", "add-also-known-as", "remove-also-known-as"},
-		SignatureAlgorithms:          []string{"EdDSA", "ES256", "ES256K"},
-		KeyAlgorithms:                []string{"Ed25519", "P-256", "secp256k1"},
-		NonceSize:                    16,
-		MaxMemoryDecompressionFactor: 3,
+func (m *MockProtocolClientProvider) create() (*MockProtocolClient, error) {
+	if len(m.protocols) == 0 {
+		return nil, fmt.Errorf("no protocol versions configured")
 	}
 
-	parser := operationparser.New(latest)
+	protocols := make([]protocol.Protocol, len(m.protocols))
+	copy(protocols, m.protocols)
+
+	sort.SliceStable(protocols, func(i, j int) bool { return protocols[i].GenesisTime < protocols[j].GenesisTime })
+
+	versions := make([]*mocks.ProtocolVersion, len(protocols))
+
+	for i, p := range protocols {
+		if i > 0 && p.GenesisTime == protocols[i-1].GenesisTime {
+			return nil, fmt.Errorf("more than one protocol version with genesis time %d", p.GenesisTime)
+		}
+
+		versions[i] = m.newVersion(p)
+	}
+
+	return &MockProtocolClient{
+		versions:   versions,
+		ledgerTime: m.ledgerTime,
+	}, nil
+}
+
+func (m *MockProtocolClientProvider) newVersion(p protocol.Protocol) *mocks.ProtocolVersion {
+	parser := operationparser.New(p)
 	cp := compression.New(compression.WithDefaultAlgorithms())
-	op := txnprovider.NewOperationProvider(latest, parser, m.casClient, cp)
-	th := txnprovider.NewOperationHandler(latest, m.casClient, cp, parser, &mocks.MetricsProvider{})
+	op := txnprovider.NewOperationProvider(p, parser, m.casClient, cp)
+	th := txnprovider.NewOperationHandler(p, m.casClient, cp, parser, &mocks.MetricsProvider{})
 	dc := doccomposer.New()
-	oa := operationapplier.New(latest, parser, dc)
+	oa := operationapplier.New(p, parser, dc)
 
 	dv := didvalidator.New()
 	dt := didtransformer.New(didtransformer.WithMethodContext(m.methodCtx), didtransformer.WithBase(m.baseEnabled))
@@ -174,10 +233,7 @@ func (m *MockProtocolClientProvider) create() *MockProtocolClient {
 	pv.OperationHandlerReturns(th)
 	pv.TransactionProcessorReturns(txnProcessor)
 
-	pv.ProtocolReturns(latest)
+	pv.ProtocolReturns(p)
 
-	return &MockProtocolClient{
-		currentVersion: pv,
-		versions:       []*mocks.ProtocolVersion{pv},
-	}
+	return pv
 }
@@ -0,0 +1,251 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package namespaceconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+
+	"github.com/trustbloc/sidetree-mock/pkg/fileutil"
+	"github.com/trustbloc/sidetree-mock/pkg/protocolconfig"
+)
+
+const didPrefix = "did:"
+
+// Namespace holds the configuration of a DID namespace that is hosted by the node
+type Namespace struct {
+	// Namespace is the DID namespace (e.g. did:sidetree)
+	Namespace string
+	// Aliases are alternative namespaces that resolve to the same DIDs
+	Aliases []string
+	// OperationPath is the REST path at which operations are submitted
+	OperationPath string
+	// ResolutionPath is the REST path at which DIDs are resolved
+	ResolutionPath string
+	// MethodContext is the JSON-LD context added to resolved documents
+	MethodContext []string
+	// BaseEnabled enables the @base property in resolved documents
+	BaseEnabled bool
+	// Protocols are the protocol versions of the namespace
+	Protocols []protocol.Protocol
+	// OperationStorePath is the path of the operation store journal (operations are kept in memory if not set)
+	OperationStorePath string
+	// OperationQueuePath is the path of the operation queue journal (queued operations are lost on restart if not set)
+	OperationQueuePath string
+}
+
+// Defaults holds the values that are used for the settings that aren't specified for a namespace
+type Defaults struct {
+	MethodContext      []string
+	BaseEnabled        bool
+	Protocols          []protocol.Protocol
+	OperationStorePath string
+	OperationQueuePath string
+}
+
+// namespaceModel is the format of a namespace in the configuration
+type namespaceModel struct {
+	Namespace          string          `json:"namespace"`
+	Aliases            []string        `json:"aliases"`
+	OperationPath      string          `json:"operationPath"`
+	ResolutionPath     string          `json:"resolutionPath"`
+	MethodContext      []string        `json:"methodContext"`
+	BaseEnabled        *bool           `json:"baseEnabled"`
+	Protocols          json.RawMessage `json:"protocols"`
+	ProtocolFile       string          `json:"protocolFile"`
+	OperationStorePath string          `json:"operationStorePath"`
+	OperationQueuePath string          `json:"operationQueuePath"`
+}
+
+// Load loads the namespaces from the YAML or JSON file at the given path. The file contains a list of
+// namespaces in the format accepted by Parse.
+func Load(path string, defaults *Defaults) ([]*Namespace, error) {
+	data, err := fileutil.ReadYAMLAsJSON(path)
+	if err != nil {
+		return nil, fmt.Errorf("load namespaces file: %w", err)
+	}
+
+	namespaces, err := Parse(data, defaults)
+	if err != nil {
+		return nil, fmt.Errorf("namespaces file [%s]: %w", path, err)
+	}
+
+	return namespaces, nil
+}
+
+// Parse parses a JSON array of namespaces, e.g.
+//
+//  [{"namespace": "did:sidetree", "aliases": ["did:sidetree:domain.com"]},
+//   {"namespace": "did:test", "protocols": [{"genesisTime": 0, "maxOperationCount": 10, ...}]}]
+//
+// Only the namespace is required. The operation and resolution paths default to /<method>/v1/operations
+// and /<method>/v1/identifiers, the protocol versions (given inline in the format accepted by protocolconfig.Parse
+// or in a protocolFile) and the other settings default to the given defaults. If a default operation store or
+// operation queue path is given then each namespace gets its own store or queue at that path suffixed with
+// the namespace.
+func Parse(data []byte, defaults *Defaults) ([]*Namespace, error) {
+	var models []*namespaceModel
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&models); err != nil {
+		return nil, fmt.Errorf("parse namespaces: %w", err)
+	}
+
+	namespaces := make([]*Namespace, 0, len(models))
+
+	for i, m := range models {
+		ns, err := newNamespace(m, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %d [%s]: %w", i, m.Namespace, err)
+		}
+
+		namespaces = append(namespaces, ns)
+	}
+
+	if err := Validate(namespaces); err != nil {
+		return nil, err
+	}
+
+	return namespaces, nil
+}
+
+// Validate checks that at least one namespace is given and that the namespaces, aliases, REST paths and
+// operation store and queue paths of the namespaces don't overlap
+func Validate(namespaces []*Namespace) error {
+	if len(namespaces) == 0 {
+		return fmt.Errorf("at least one namespace is required")
+	}
+
+	var errs []string
+
+	names := make(map[string]string)
+	paths := make(map[string]string)
+	files := make(map[string]string)
+
+	for _, ns := range namespaces {
+		if !strings.HasPrefix(ns.Namespace, didPrefix) || len(ns.Namespace) == len(didPrefix) {
+			errs = append(errs, fmt.Sprintf("namespace [%s] must start with %s followed by the method name",
+				ns.Namespace, didPrefix))
+		}
+
+		for _, name := range append([]string{ns.Namespace}, ns.Aliases...) {
+			if other, ok := names[name]; ok {
+				errs = append(errs, fmt.Sprintf("[%s] is used by namespaces [%s] and [%s]", name, other, ns.Namespace))
+			}
+
+			names[name] = ns.Namespace
+		}
+
+		for _, path := range []string{ns.OperationPath, ns.ResolutionPath} {
+			if other, ok := paths[path]; ok {
+				errs = append(errs, fmt.Sprintf("path [%s] is used by namespaces [%s] and [%s]", path, other, ns.Namespace))
+			}
+
+			paths[path] = ns.Namespace
+		}
+
+		for _, file := range []string{ns.OperationStorePath, ns.OperationQueuePath} {
+			if file == "" {
+				continue
+			}
+
+			if other, ok := files[file]; ok {
+				errs = append(errs, fmt.Sprintf("file [%s] is used by namespaces [%s] and [%s]",
+					file, other, ns.Namespace))
+			}
+
+			files[file] = ns.Namespace
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid namespaces: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// DefaultOperationPath returns the default operation path of the given namespace (e.g. /sidetree/v1/operations)
+func DefaultOperationPath(namespace string) string {
+	return fmt.Sprintf("/%s/v1/operations", methodPath(namespace))
+}
+
+// DefaultResolutionPath returns the default resolution path of the given namespace (e.g. /sidetree/v1/identifiers)
+func DefaultResolutionPath(namespace string) string {
+	return fmt.Sprintf("/%s/v1/identifiers", methodPath(namespace))
+}
+
+func newNamespace(m *namespaceModel, defaults *Defaults) (*Namespace, error) {
+	ns := &Namespace{
+		Namespace:          m.Namespace,
+		Aliases:            m.Aliases,
+		OperationPath:      m.OperationPath,
+		ResolutionPath:     m.ResolutionPath,
+		MethodContext:      m.MethodContext,
+		BaseEnabled:        defaults.BaseEnabled,
+		Protocols:          defaults.Protocols,
+		OperationStorePath: m.OperationStorePath,
+		OperationQueuePath: m.OperationQueuePath,
+	}
+
+	if ns.OperationPath == "" {
+		ns.OperationPath = DefaultOperationPath(m.Namespace)
+	}
+
+	if ns.ResolutionPath == "" {
+		ns.ResolutionPath = DefaultResolutionPath(m.Namespace)
+	}
+
+	if ns.MethodContext == nil {
+		ns.MethodContext = defaults.MethodContext
+	}
+
+	if m.BaseEnabled != nil {
+		ns.BaseEnabled = *m.BaseEnabled
+	}
+
+	if ns.OperationStorePath == "" && defaults.OperationStorePath != "" {
+		ns.OperationStorePath = defaults.OperationStorePath + "-" + fileSuffix(m.Namespace)
+	}
+
+	if ns.OperationQueuePath == "" && defaults.OperationQueuePath != "" {
+		ns.OperationQueuePath = defaults.OperationQueuePath + "-" + fileSuffix(m.Namespace)
+	}
+
+	var err error
+
+	switch {
+	case len(m.Protocols) > 0 && m.ProtocolFile != "":
+		return nil, fmt.Errorf("protocols and protocolFile must not both be set")
+	case len(m.Protocols) > 0:
+		ns.Protocols, err = protocolconfig.Parse(m.Protocols)
+	case m.ProtocolFile != "":
+		ns.Protocols, err = protocolconfig.Load(m.ProtocolFile)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// methodPath returns the method specific part of the namespace with colons replaced by slashes
+func methodPath(namespace string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(namespace, didPrefix), ":", "/")
+}
+
+// fileSuffix returns the namespace with colons replaced by underscores
+func fileSuffix(namespace string) string {
+	return strings.ReplaceAll(namespace, ":", "_")
+}
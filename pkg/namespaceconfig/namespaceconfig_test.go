@@ -0,0 +1,220 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package namespaceconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+func TestParse(t *testing.T) {
+	defaults := &Defaults{
+		MethodContext:      []string{"https://example.com/context"},
+		BaseEnabled:        true,
+		Protocols:          []protocol.Protocol{mocks.DefaultProtocol()},
+		OperationStorePath: "/data/opstore",
+		OperationQueuePath: "/data/opqueue",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		namespaces, err := Parse([]byte(`[
+			{"namespace": "did:sidetree", "aliases": ["did:sidetree:domain.com"]},
+			{
+				"namespace": "did:test:net",
+				"operationPath": "/test/operations",
+				"resolutionPath": "/test/identifiers",
+				"methodContext": [],
+				"baseEnabled": false,
+				"protocols": [`+protocolJSON(t, 10)+`],
+				"operationStorePath": "/data/test",
+				"operationQueuePath": "/data/test-queue"
+			}
+		]`), defaults)
+		require.NoError(t, err)
+		require.Len(t, namespaces, 2)
+
+		ns := namespaces[0]
+		require.Equal(t, "did:sidetree", ns.Namespace)
+		require.Equal(t, []string{"did:sidetree:domain.com"}, ns.Aliases)
+		require.Equal(t, "/sidetree/v1/operations", ns.OperationPath)
+		require.Equal(t, "/sidetree/v1/identifiers", ns.ResolutionPath)
+		require.Equal(t, defaults.MethodContext, ns.MethodContext)
+		require.True(t, ns.BaseEnabled)
+		require.Equal(t, defaults.Protocols, ns.Protocols)
+		require.Equal(t, "/data/opstore-did_sidetree", ns.OperationStorePath)
+		require.Equal(t, "/data/opqueue-did_sidetree", ns.OperationQueuePath)
+
+		ns = namespaces[1]
+		require.Equal(t, "did:test:net", ns.Namespace)
+		require.Empty(t, ns.Aliases)
+		require.Equal(t, "/test/operations", ns.OperationPath)
+		require.Equal(t, "/test/identifiers", ns.ResolutionPath)
+		require.Empty(t, ns.MethodContext)
+		require.False(t, ns.BaseEnabled)
+		require.Len(t, ns.Protocols, 1)
+		require.Equal(t, uint(10), ns.Protocols[0].MaxOperationCount)
+		require.Equal(t, "/data/test", ns.OperationStorePath)
+		require.Equal(t, "/data/test-queue", ns.OperationQueuePath)
+	})
+
+	t.Run("default paths", func(t *testing.T) {
+		require.Equal(t, "/test/net/v1/operations", DefaultOperationPath("did:test:net"))
+		require.Equal(t, "/test/net/v1/identifiers", DefaultResolutionPath("did:test:net"))
+	})
+
+	t.Run("in-memory operation stores", func(t *testing.T) {
+		namespaces, err := Parse([]byte(`[{"namespace": "did:sidetree"}]`), &Defaults{})
+		require.NoError(t, err)
+		require.Empty(t, namespaces[0].OperationStorePath)
+		require.Empty(t, namespaces[0].OperationQueuePath)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := Parse([]byte(`{`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse namespaces")
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		_, err := Parse([]byte(`[{"namespace": "did:sidetree", "alias": ["did:alias"]}]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "alias"`)
+	})
+
+	t.Run("invalid protocols", func(t *testing.T) {
+		_, err := Parse([]byte(`[{"namespace": "did:sidetree", "protocols": [`+protocolJSON(t, 0)+`]}]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "namespace 0 [did:sidetree]: invalid protocol versions")
+	})
+
+	t.Run("incomplete protocols", func(t *testing.T) {
+		_, err := Parse([]byte(`[{"namespace": "did:sidetree", "protocols": [{"maxOperationCount": 10}]}]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "namespace 0 [did:sidetree]: parse protocol version 0: missing parameters")
+	})
+
+	t.Run("protocols and protocol file", func(t *testing.T) {
+		_, err := Parse([]byte(`[{"namespace": "did:sidetree", "protocols": [{}], "protocolFile": "protocol.yaml"}]`),
+			defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "protocols and protocolFile must not both be set")
+	})
+
+	t.Run("protocol file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "protocol.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte("- "+protocolJSON(t, 5)), 0o600))
+
+		namespaces, err := Parse([]byte(`[{"namespace": "did:sidetree", "protocolFile": "`+path+`"}]`), defaults)
+		require.NoError(t, err)
+		require.Equal(t, uint(5), namespaces[0].Protocols[0].MaxOperationCount)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	defaults := &Defaults{Protocols: []protocol.Protocol{mocks.DefaultProtocol()}}
+
+	t.Run("no namespaces", func(t *testing.T) {
+		_, err := Parse([]byte(`[]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one namespace is required")
+	})
+
+	t.Run("invalid namespace", func(t *testing.T) {
+		_, err := Parse([]byte(`[{"namespace": "sidetree"}, {"namespace": "did:"}]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "namespace [sidetree] must start with did: followed by the method name")
+		require.Contains(t, err.Error(), "namespace [did:] must start with did: followed by the method name")
+	})
+
+	t.Run("duplicate namespace", func(t *testing.T) {
+		_, err := Parse([]byte(`[
+			{"namespace": "did:sidetree", "aliases": ["did:alias"]},
+			{"namespace": "did:test", "aliases": ["did:alias"], "resolutionPath": "/sidetree/v1/identifiers"},
+			{"namespace": "did:sidetree", "operationPath": "/a", "resolutionPath": "/b"}
+		]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "[did:alias] is used by namespaces [did:sidetree] and [did:test]")
+		require.Contains(t, err.Error(),
+			"path [/sidetree/v1/identifiers] is used by namespaces [did:sidetree] and [did:test]")
+		require.Contains(t, err.Error(), "[did:sidetree] is used by namespaces [did:sidetree] and [did:sidetree]")
+	})
+
+	t.Run("duplicate operation store", func(t *testing.T) {
+		_, err := Parse([]byte(`[
+			{"namespace": "did:sidetree", "operationStorePath": "/data/ops"},
+			{"namespace": "did:test", "operationStorePath": "/data/ops"}
+		]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file [/data/ops] is used by namespaces [did:sidetree] and [did:test]")
+	})
+
+	t.Run("operation queue and operation store share file", func(t *testing.T) {
+		_, err := Parse([]byte(`[
+			{"namespace": "did:sidetree", "operationStorePath": "/data/ops"},
+			{"namespace": "did:test", "operationQueuePath": "/data/ops"}
+		]`), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file [/data/ops] is used by namespaces [did:sidetree] and [did:test]")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	defaults := &Defaults{Protocols: []protocol.Protocol{mocks.DefaultProtocol()}}
+
+	t.Run("success", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "namespaces.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`
+- namespace: did:sidetree
+  aliases:
+    - did:sidetree:domain.com
+- namespace: did:test
+  protocols:
+    - `+protocolJSON(t, 10)+`
+`), 0o600))
+
+		namespaces, err := Load(path, defaults)
+		require.NoError(t, err)
+		require.Len(t, namespaces, 2)
+		require.Equal(t, "did:test", namespaces[1].Namespace)
+		require.Equal(t, uint(10), namespaces[1].Protocols[0].MaxOperationCount)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "load namespaces file")
+	})
+
+	t.Run("invalid namespaces", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "namespaces.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte("[]"), 0o600))
+
+		_, err := Load(path, defaults)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one namespace is required")
+	})
+}
+
+// protocolJSON returns the JSON of the default protocol with the given maximum operation count
+func protocolJSON(t *testing.T, maxOperationCount uint) string {
+	t.Helper()
+
+	p := mocks.DefaultProtocol()
+	p.MaxOperationCount = maxOperationCount
+
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+
+	return string(data)
+}
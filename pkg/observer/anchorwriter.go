@@ -0,0 +1,552 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package observer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/batch"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/txnprovider"
+
+	"github.com/trustbloc/sidetree-mock/pkg/journal"
+)
+
+// AnchorWriter simulates a ledger by recording each anchor string as a Sidetree transaction.
+// Transactions are kept in memory unless the anchor writer was created with NewFileAnchorWriter,
+// in which case they are also appended to a journal so that the ledger survives a restart.
+//
+// If a block time is configured then anchors are held pending and become visible (are assigned a
+// transaction time and number) only once the block time has elapsed. In manual mining mode anchors are held
+// pending until blocks are mined with Mine. Otherwise anchors are visible as soon as they are written and are
+// picked up by the observer when it next polls the ledger, or - with synchronous delivery - subscribers are
+// notified before WriteAnchor returns.
+//
+// The transaction time of a transaction is the height of the block that the transaction was included in.
+// Reorg removes transactions from the end of the ledger to simulate a fork.
+type AnchorWriter struct {
+	mutex        sync.RWMutex
+	namespace    string
+	txns         []*txn.SidetreeTxn
+	pending      []*Anchor
+	height       uint64
+	blockTime    time.Duration
+	manualMining bool
+	synchronous  bool
+	subscribers  []func()
+	reorgSubs    []func(orphaned []*txn.SidetreeTxn)
+	journal      *journal.Journal
+}
+
+// Anchor is an anchor string that is not (yet) included in a ledger transaction. The transaction is recorded
+// for the default namespace of the anchor writer if no namespace is set.
+type Anchor struct {
+	Anchor          string `json:"anchor"`
+	ProtocolVersion uint64 `json:"protocolVersion"`
+	Namespace       string `json:"namespace,omitempty"`
+}
+
+// ledgerRecord is the journal record written for each change to the ledger:
+// - Transaction is a visible transaction
+// - Pending is an anchor that was written but isn't visible yet
+// - Block holds the number of pending anchors that became visible in a new block (which may be empty)
+// - Orphan holds the number of transactions that were removed from the end of the ledger by a reorg
+type ledgerRecord struct {
+	Transaction *txn.SidetreeTxn `json:"transaction,omitempty"`
+	Pending     *Anchor          `json:"pending,omitempty"`
+	Block       *blockRecord     `json:"block,omitempty"`
+	Orphan      *orphanRecord    `json:"orphan,omitempty"`
+}
+
+type blockRecord struct {
+	Count int `json:"count"`
+}
+
+type orphanRecord struct {
+	Count int `json:"count"`
+}
+
+// AnchorWriterOption is an anchor writer option
+type AnchorWriterOption func(w *AnchorWriter)
+
+// WithBlockTime sets the delay between writing an anchor and the anchor becoming visible
+func WithBlockTime(blockTime time.Duration) AnchorWriterOption {
+	return func(w *AnchorWriter) {
+		w.blockTime = blockTime
+	}
+}
+
+// WithManualMining holds anchors pending until blocks are mined with Mine
+func WithManualMining() AnchorWriterOption {
+	return func(w *AnchorWriter) {
+		w.manualMining = true
+	}
+}
+
+// WithSynchronousDelivery notifies subscribers before WriteAnchor returns of anchors that are visible as soon as
+// they are written (i.e. if there is no block time and blocks aren't mined manually)
+func WithSynchronousDelivery() AnchorWriterOption {
+	return func(w *AnchorWriter) {
+		w.synchronous = true
+	}
+}
+
+// NewAnchorWriter returns an in-memory anchor writer for the given namespace
+func NewAnchorWriter(namespace string, opts ...AnchorWriterOption) *AnchorWriter {
+	w := &AnchorWriter{namespace: namespace}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// NewFileAnchorWriter returns an anchor writer for the given namespace that persists transactions to
+// the journal at the given path. Transactions already in the journal are loaded into the ledger.
+func NewFileAnchorWriter(namespace, path string, opts ...AnchorWriterOption) (*AnchorWriter, error) {
+	w := NewAnchorWriter(namespace, opts...)
+
+	j, err := journal.Open(path, func(data []byte) error {
+		record := &ledgerRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return err
+		}
+
+		switch {
+		case record.Transaction != nil:
+			w.txns = append(w.txns, record.Transaction)
+			w.height = record.Transaction.TransactionTime + 1
+		case record.Pending != nil:
+			w.pending = append(w.pending, record.Pending)
+		case record.Block != nil:
+			if record.Block.Count > len(w.pending) {
+				return fmt.Errorf("block of %d anchors exceeds %d pending anchors", record.Block.Count, len(w.pending))
+			}
+
+			w.confirm(record.Block.Count)
+		case record.Orphan != nil:
+			if record.Orphan.Count > len(w.txns) {
+				return fmt.Errorf("orphan of %d transactions exceeds %d transactions", record.Orphan.Count, len(w.txns))
+			}
+
+			w.txns = w.txns[:len(w.txns)-record.Orphan.Count]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open ledger: %w", err)
+	}
+
+	w.journal = j
+
+	logger.Infof("loaded %d transactions and %d pending anchors from ledger at [%s]", len(w.txns), len(w.pending), path)
+
+	// anchors that were pending when the node stopped become visible after another block time
+	if !w.manualMining {
+		for _, p := range w.pending {
+			w.schedule(p)
+		}
+	}
+
+	return w, nil
+}
+
+// Subscribe registers a function that is invoked whenever new transactions become visible
+func (w *AnchorWriter) Subscribe(subscriber func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.subscribers = append(w.subscribers, subscriber)
+}
+
+// SubscribeReorg registers a function that is invoked with the orphaned transactions whenever
+// transactions are removed from the ledger by a reorg
+func (w *AnchorWriter) SubscribeReorg(subscriber func(orphaned []*txn.SidetreeTxn)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.reorgSubs = append(w.reorgSubs, subscriber)
+}
+
+// ForNamespace returns an anchor writer that records transactions for the given namespace in this ledger.
+// This allows several namespaces to share a single ledger.
+func (w *AnchorWriter) ForNamespace(namespace string) batch.AnchorWriter {
+	return &namespaceAnchorWriter{AnchorWriter: w, namespace: namespace}
+}
+
+// WriteAnchor writes the anchor string as a transaction to the ledger
+func (w *AnchorWriter) WriteAnchor(anchor string, _ []*protocol.AnchorDocument, _ []*operation.Reference, protocolVersion uint64) error {
+	return w.writeAnchor(&Anchor{Anchor: anchor, ProtocolVersion: protocolVersion})
+}
+
+func (w *AnchorWriter) writeAnchor(p *Anchor) error {
+	if w.blockTime == 0 && !w.manualMining {
+		return w.writeTransaction(p)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.journal != nil {
+		if err := w.journal.Append(&ledgerRecord{Pending: p}); err != nil {
+			return err
+		}
+	}
+
+	w.pending = append(w.pending, p)
+
+	if w.manualMining {
+		logger.Debugf("anchor will become visible when the next block is mined: %s", p.Anchor)
+
+		return nil
+	}
+
+	w.schedule(p)
+
+	logger.Debugf("anchor will become visible in %s: %s", w.blockTime, p.Anchor)
+
+	return nil
+}
+
+// Mine mines the given number of blocks. The first block includes all pending anchors and
+// the remaining blocks are empty. The transactions that became visible are returned.
+func (w *AnchorWriter) Mine(blocks int) ([]*txn.SidetreeTxn, error) {
+	if blocks < 1 {
+		return nil, fmt.Errorf("number of blocks must be greater than zero")
+	}
+
+	w.mutex.Lock()
+
+	first := len(w.txns)
+
+	for i := 0; i < blocks; i++ {
+		count := 0
+		if i == 0 {
+			count = len(w.pending)
+		}
+
+		if w.journal != nil {
+			if err := w.journal.Append(&ledgerRecord{Block: &blockRecord{Count: count}}); err != nil {
+				w.mutex.Unlock()
+
+				return nil, err
+			}
+		}
+
+		w.confirm(count)
+	}
+
+	txns := make([]*txn.SidetreeTxn, len(w.txns)-first)
+	copy(txns, w.txns[first:])
+
+	height := w.height
+
+	w.mutex.Unlock()
+
+	logger.Infof("mined %d blocks with %d transactions - block height is %d", blocks, len(txns), height)
+
+	if len(txns) > 0 {
+		w.notify()
+	}
+
+	return txns, nil
+}
+
+// Reorg simulates a ledger reorganisation: the given number of transactions are removed from the end of the ledger
+// and the given replacement anchors (if any) are included in a new block. The transaction numbers of the orphaned
+// transactions are reused by the replacements. Pending anchors are not affected.
+// The orphaned transactions and the replacement transactions are returned.
+func (w *AnchorWriter) Reorg(count int, replacements []*Anchor) ([]*txn.SidetreeTxn, []*txn.SidetreeTxn, error) {
+	w.mutex.Lock()
+
+	if count < 1 || count > len(w.txns) {
+		w.mutex.Unlock()
+
+		return nil, nil, fmt.Errorf("number of orphaned transactions must be between 1 and %d", len(w.txns))
+	}
+
+	if w.journal != nil {
+		if err := w.journal.Append(&ledgerRecord{Orphan: &orphanRecord{Count: count}}); err != nil {
+			w.mutex.Unlock()
+
+			return nil, nil, err
+		}
+	}
+
+	first := len(w.txns) - count
+
+	orphaned := make([]*txn.SidetreeTxn, count)
+	copy(orphaned, w.txns[first:])
+
+	w.txns = w.txns[:first]
+
+	replaced, err := w.appendBlock(replacements)
+
+	w.mutex.Unlock()
+
+	logger.Infof("reorg orphaned %d transactions starting at transaction number %d and included %d replacements",
+		count, first, len(replaced))
+
+	w.notifyReorg(orphaned)
+
+	if len(replaced) > 0 {
+		w.notify()
+	}
+
+	return orphaned, replaced, err
+}
+
+// Height returns the number of blocks in the ledger
+func (w *AnchorWriter) Height() uint64 {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return w.height
+}
+
+// Pending returns the number of anchors that were written but aren't visible yet
+func (w *AnchorWriter) Pending() int {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return len(w.pending)
+}
+
+// Read returns the transaction following the given transaction number (if any) and whether
+// there are more transactions after the returned one
+func (w *AnchorWriter) Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	next := sinceTransactionNumber + 1
+	if next < 0 || next >= len(w.txns) {
+		return false, nil
+	}
+
+	t := *w.txns[next]
+
+	return next < len(w.txns)-1, &t
+}
+
+// Transactions returns all visible transactions in the ledger
+func (w *AnchorWriter) Transactions() []*txn.SidetreeTxn {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	txns := make([]*txn.SidetreeTxn, len(w.txns))
+	copy(txns, w.txns)
+
+	return txns
+}
+
+// Restore replaces the contents of the ledger with the given transactions. Pending anchors are discarded.
+func (w *AnchorWriter) Restore(txns []*txn.SidetreeTxn) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// the ledger in memory is only replaced once the journal holds the restored transactions
+	if w.journal != nil {
+		records := make([]interface{}, len(txns))
+		for i, t := range txns {
+			records[i] = &ledgerRecord{Transaction: t}
+		}
+
+		if err := w.journal.Replace(records...); err != nil {
+			return err
+		}
+	}
+
+	w.txns = make([]*txn.SidetreeTxn, len(txns))
+	copy(w.txns, txns)
+
+	w.pending = nil
+	w.height = 0
+
+	if len(txns) > 0 {
+		w.height = txns[len(txns)-1].TransactionTime + 1
+	}
+
+	return nil
+}
+
+// writeTransaction makes the given anchor visible right away
+func (w *AnchorWriter) writeTransaction(p *Anchor) error {
+	w.mutex.Lock()
+
+	txns, err := w.appendBlock([]*Anchor{p})
+
+	w.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("recorded sidetree txn %d in ledger: %s", txns[0].TransactionNumber, txns[0].AnchorString)
+
+	if w.synchronous {
+		w.notify()
+	}
+
+	return nil
+}
+
+// schedule makes the given pending anchor (and any anchors pending before it) visible after the block time
+func (w *AnchorWriter) schedule(p *Anchor) {
+	time.AfterFunc(w.blockTime, func() {
+		if err := w.release(p); err != nil {
+			logger.Errorf("Failed to release pending anchor [%s]: %s", p.Anchor, err)
+		}
+	})
+}
+
+// release makes the given pending anchor and all anchors pending before it visible in a new block.
+// Nothing is done if the anchor is no longer pending.
+func (w *AnchorWriter) release(p *Anchor) error {
+	w.mutex.Lock()
+
+	count := 0
+
+	for i, pending := range w.pending {
+		if pending == p {
+			count = i + 1
+
+			break
+		}
+	}
+
+	if count == 0 {
+		w.mutex.Unlock()
+
+		return nil
+	}
+
+	if w.journal != nil {
+		if err := w.journal.Append(&ledgerRecord{Block: &blockRecord{Count: count}}); err != nil {
+			w.mutex.Unlock()
+
+			return err
+		}
+	}
+
+	w.confirm(count)
+
+	w.mutex.Unlock()
+
+	logger.Debugf("%d pending anchors are now visible in ledger", count)
+
+	w.notify()
+
+	return nil
+}
+
+// appendBlock includes the given anchors in a new block. Nothing is done if no anchors are provided.
+func (w *AnchorWriter) appendBlock(anchors []*Anchor) ([]*txn.SidetreeTxn, error) {
+	if len(anchors) == 0 {
+		return nil, nil
+	}
+
+	var (
+		txns []*txn.SidetreeTxn
+		err  error
+	)
+
+	for _, a := range anchors {
+		t := w.newTransaction(a, w.height)
+
+		if w.journal != nil {
+			if err = w.journal.Append(&ledgerRecord{Transaction: t}); err != nil {
+				break
+			}
+		}
+
+		w.txns = append(w.txns, t)
+		txns = append(txns, t)
+	}
+
+	if len(txns) > 0 {
+		w.height++
+	}
+
+	return txns, err
+}
+
+// confirm moves the given number of pending anchors into a new block
+func (w *AnchorWriter) confirm(count int) {
+	for _, p := range w.pending[:count] {
+		w.txns = append(w.txns, w.newTransaction(p, w.height))
+	}
+
+	w.pending = w.pending[count:]
+	w.height++
+}
+
+func (w *AnchorWriter) newTransaction(p *Anchor, block uint64) *txn.SidetreeTxn {
+	namespace := p.Namespace
+	if namespace == "" {
+		namespace = w.namespace
+	}
+
+	return &txn.SidetreeTxn{
+		Namespace:          namespace,
+		TransactionTime:    block,
+		TransactionNumber:  uint64(len(w.txns)),
+		AnchorString:       p.Anchor,
+		CanonicalReference: canonicalReference(p.Anchor),
+		ProtocolVersion:    p.ProtocolVersion,
+	}
+}
+
+// canonicalReference returns the canonical reference of the transaction with the given anchor string, which is
+// the address of the core index file of the batch. The canonical reference of a transaction becomes the version ID
+// of the DID documents that were changed by the operations in the batch.
+func canonicalReference(anchor string) string {
+	ad, err := txnprovider.ParseAnchorData(anchor)
+	if err != nil {
+		logger.Warnf("Unable to derive canonical reference from anchor [%s]: %s", anchor, err)
+
+		return ""
+	}
+
+	return ad.CoreIndexFileURI
+}
+
+func (w *AnchorWriter) notifyReorg(orphaned []*txn.SidetreeTxn) {
+	w.mutex.RLock()
+	subscribers := w.reorgSubs
+	w.mutex.RUnlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(orphaned)
+	}
+}
+
+func (w *AnchorWriter) notify() {
+	w.mutex.RLock()
+	subscribers := w.subscribers
+	w.mutex.RUnlock()
+
+	for _, subscriber := range subscribers {
+		subscriber()
+	}
+}
+
+// namespaceAnchorWriter records the anchors of a single namespace in a shared ledger
+type namespaceAnchorWriter struct {
+	*AnchorWriter
+	namespace string
+}
+
+// WriteAnchor writes the anchor string as a transaction of the namespace to the ledger
+func (w *namespaceAnchorWriter) WriteAnchor(anchor string, _ []*protocol.AnchorDocument, _ []*operation.Reference, protocolVersion uint64) error {
+	return w.writeAnchor(&Anchor{Anchor: anchor, ProtocolVersion: protocolVersion, Namespace: w.namespace})
+}
@@ -0,0 +1,289 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package observer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+func TestAnchorWriter(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		w := NewAnchorWriter(mocks.DefaultNS)
+
+		more, txn := w.Read(-1)
+		require.False(t, more)
+		require.Nil(t, txn)
+
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.NoError(t, w.WriteAnchor("1.anchor2", nil, nil, 10))
+
+		more, txn = w.Read(-1)
+		require.True(t, more)
+		require.NotNil(t, txn)
+		require.Equal(t, "1.anchor1", txn.AnchorString)
+		require.Equal(t, "anchor1", txn.CanonicalReference)
+		require.Equal(t, mocks.DefaultNS, txn.Namespace)
+		require.Equal(t, uint64(0), txn.TransactionNumber)
+
+		more, txn = w.Read(0)
+		require.False(t, more)
+		require.NotNil(t, txn)
+		require.Equal(t, "1.anchor2", txn.AnchorString)
+		require.Equal(t, uint64(1), txn.TransactionNumber)
+		require.Equal(t, uint64(10), txn.ProtocolVersion)
+
+		more, txn = w.Read(1)
+		require.False(t, more)
+		require.Nil(t, txn)
+	})
+
+	t.Run("persistence", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ledger")
+
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.NoError(t, w.WriteAnchor("1.anchor2", nil, nil, 0))
+
+		w, err = NewFileAnchorWriter(mocks.DefaultNS, path)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteAnchor("1.anchor3", nil, nil, 0))
+
+		_, txn := w.Read(1)
+		require.NotNil(t, txn)
+		require.Equal(t, "1.anchor3", txn.AnchorString)
+		require.Equal(t, uint64(2), txn.TransactionNumber)
+	})
+
+	t.Run("namespaces", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ledger")
+
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.NoError(t, w.ForNamespace("did:test").WriteAnchor("1.anchor2", nil, nil, 0))
+
+		// the namespace of each transaction is restored from the journal
+		w, err = NewFileAnchorWriter(mocks.DefaultNS, path)
+		require.NoError(t, err)
+
+		txns := w.Transactions()
+		require.Len(t, txns, 2)
+		require.Equal(t, mocks.DefaultNS, txns[0].Namespace)
+		require.Equal(t, "did:test", txns[1].Namespace)
+		require.Equal(t, uint64(1), txns[1].TransactionNumber)
+	})
+}
+
+func TestAnchorWriter_BlockTime(t *testing.T) {
+	t.Run("subscribers are not notified without block time", func(t *testing.T) {
+		w := NewAnchorWriter(mocks.DefaultNS)
+
+		notified := 0
+		w.Subscribe(func() { notified++ })
+
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.Zero(t, notified)
+
+		_, txn := w.Read(-1)
+		require.NotNil(t, txn)
+	})
+
+	t.Run("subscribers are notified synchronously with synchronous delivery", func(t *testing.T) {
+		w := NewAnchorWriter(mocks.DefaultNS, WithSynchronousDelivery())
+
+		notified := 0
+		w.Subscribe(func() { notified++ })
+
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.Equal(t, 1, notified)
+
+		_, txn := w.Read(-1)
+		require.NotNil(t, txn)
+	})
+
+	t.Run("anchor is visible after block time", func(t *testing.T) {
+		w := NewAnchorWriter(mocks.DefaultNS, WithBlockTime(200*time.Millisecond))
+
+		notified := make(chan struct{}, 2)
+		w.Subscribe(func() { notified <- struct{}{} })
+
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.NoError(t, w.WriteAnchor("1.anchor2", nil, nil, 0))
+
+		_, txn := w.Read(-1)
+		require.Nil(t, txn)
+
+		<-notified
+
+		require.Eventually(t, func() bool { return len(w.Transactions()) == 2 }, time.Second, 10*time.Millisecond)
+
+		more, txn := w.Read(-1)
+		require.True(t, more)
+		require.NotNil(t, txn)
+		require.Equal(t, "1.anchor1", txn.AnchorString)
+
+		_, txn = w.Read(0)
+		require.NotNil(t, txn)
+		require.Equal(t, "1.anchor2", txn.AnchorString)
+		require.Equal(t, uint64(1), txn.TransactionNumber)
+	})
+
+	t.Run("pending anchors survive restart", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ledger")
+
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path, WithBlockTime(time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+
+		w, err = NewFileAnchorWriter(mocks.DefaultNS, path, WithBlockTime(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, w.pending, 1)
+		require.NoError(t, w.release(w.pending[0]))
+
+		w, err = NewFileAnchorWriter(mocks.DefaultNS, path, WithBlockTime(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, w.pending)
+		require.Len(t, w.Transactions(), 1)
+		require.Equal(t, "1.anchor1", w.Transactions()[0].AnchorString)
+	})
+}
+
+func TestAnchorWriter_Mine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+
+	t.Run("anchors are visible after mining", func(t *testing.T) {
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path, WithManualMining())
+		require.NoError(t, err)
+
+		notified := 0
+		w.Subscribe(func() { notified++ })
+
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.NoError(t, w.WriteAnchor("1.anchor2", nil, nil, 0))
+		require.Equal(t, 2, w.Pending())
+		require.Empty(t, w.Transactions())
+
+		txns, err := w.Mine(3)
+		require.NoError(t, err)
+		require.Len(t, txns, 2)
+		require.Equal(t, 1, notified)
+		require.Equal(t, uint64(0), txns[0].TransactionTime)
+		require.Equal(t, uint64(0), txns[1].TransactionTime)
+		require.Equal(t, uint64(1), txns[1].TransactionNumber)
+		require.Equal(t, uint64(3), w.Height())
+		require.Equal(t, 0, w.Pending())
+
+		txns, err = w.Mine(1)
+		require.NoError(t, err)
+		require.Empty(t, txns)
+		require.Equal(t, 1, notified)
+
+		require.NoError(t, w.WriteAnchor("1.anchor3", nil, nil, 0))
+	})
+
+	t.Run("ledger is restored from journal", func(t *testing.T) {
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path, WithManualMining())
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), w.Height())
+		require.Equal(t, 1, w.Pending())
+		require.Len(t, w.Transactions(), 2)
+
+		txns, err := w.Mine(1)
+		require.NoError(t, err)
+		require.Len(t, txns, 1)
+		require.Equal(t, uint64(4), txns[0].TransactionTime)
+		require.Equal(t, uint64(2), txns[0].TransactionNumber)
+	})
+
+	t.Run("invalid number of blocks", func(t *testing.T) {
+		w := NewAnchorWriter(mocks.DefaultNS, WithManualMining())
+
+		_, err := w.Mine(0)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "number of blocks must be greater than zero")
+	})
+}
+
+func TestAnchorWriter_Reorg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+
+	t.Run("orphan and replace transactions", func(t *testing.T) {
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path, WithSynchronousDelivery())
+		require.NoError(t, err)
+
+		var orphanedTxns []*txn.SidetreeTxn
+		w.SubscribeReorg(func(orphaned []*txn.SidetreeTxn) { orphanedTxns = orphaned })
+
+		notified := 0
+		w.Subscribe(func() { notified++ })
+
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+		require.NoError(t, w.WriteAnchor("1.anchor2", nil, nil, 0))
+		require.NoError(t, w.WriteAnchor("1.anchor3", nil, nil, 0))
+		require.Equal(t, 3, notified)
+
+		orphaned, replaced, err := w.Reorg(2, []*Anchor{{Anchor: "1.replacement"}})
+		require.NoError(t, err)
+		require.Len(t, orphaned, 2)
+		require.Equal(t, "1.anchor2", orphaned[0].AnchorString)
+		require.Equal(t, orphaned, orphanedTxns)
+		require.Equal(t, 4, notified)
+
+		require.Len(t, replaced, 1)
+		require.Equal(t, "1.replacement", replaced[0].AnchorString)
+		require.Equal(t, uint64(1), replaced[0].TransactionNumber)
+		require.Equal(t, uint64(3), replaced[0].TransactionTime)
+		require.Equal(t, uint64(4), w.Height())
+
+		orphaned, replaced, err = w.Reorg(1, nil)
+		require.NoError(t, err)
+		require.Len(t, orphaned, 1)
+		require.Empty(t, replaced)
+		require.Equal(t, 4, notified)
+		require.Len(t, w.Transactions(), 1)
+	})
+
+	t.Run("ledger is restored from journal", func(t *testing.T) {
+		w, err := NewFileAnchorWriter(mocks.DefaultNS, path)
+		require.NoError(t, err)
+		require.Len(t, w.Transactions(), 1)
+		require.Equal(t, "1.anchor1", w.Transactions()[0].AnchorString)
+		require.Equal(t, uint64(4), w.Height())
+	})
+
+	t.Run("invalid number of transactions", func(t *testing.T) {
+		w := NewAnchorWriter(mocks.DefaultNS)
+		require.NoError(t, w.WriteAnchor("1.anchor1", nil, nil, 0))
+
+		_, _, err := w.Reorg(2, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "number of orphaned transactions must be between 1 and 1")
+	})
+}
+
+func TestCursor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursor")
+
+	c, err := NewFileCursor(path)
+	require.NoError(t, err)
+	require.Equal(t, -1, c.Get())
+	require.NoError(t, c.Set(5))
+
+	c, err = NewFileCursor(path)
+	require.NoError(t, err)
+	require.Equal(t, 5, c.Get())
+
+	_, err = NewFileCursor(t.TempDir())
+	require.Error(t, err)
+}
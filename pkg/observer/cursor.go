@@ -0,0 +1,84 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/trustbloc/sidetree-mock/pkg/fileutil"
+)
+
+// Cursor holds the number of the last ledger transaction processed by the observer.
+// The value is kept in memory unless the cursor was created with NewFileCursor.
+type Cursor struct {
+	mutex sync.RWMutex
+	value int
+	path  string
+}
+
+// NewCursor returns an in-memory cursor positioned before the first transaction
+func NewCursor() *Cursor {
+	return &Cursor{value: -1}
+}
+
+// NewFileCursor returns a cursor that is persisted to the file at the given path.
+// If the file exists then the cursor resumes from the value stored in the file.
+func NewFileCursor(path string) (*Cursor, error) {
+	c := &Cursor{value: -1, path: path}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create cursor directory: %w", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read cursor: %w", err)
+	}
+
+	c.value, err = strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parse cursor [%s]: %w", path, err)
+	}
+
+	logger.Infof("resuming observer from transaction number %d", c.value)
+
+	return c, nil
+}
+
+// Get returns the number of the last processed transaction (-1 if no transactions have been processed)
+func (c *Cursor) Get() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.value
+}
+
+// Set sets the number of the last processed transaction
+func (c *Cursor) Set(value int) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.path != "" {
+		if err := fileutil.WriteFileAtomic(c.path, []byte(strconv.Itoa(value))); err != nil {
+			return fmt.Errorf("save cursor: %w", err)
+		}
+	}
+
+	c.value = value
+
+	return nil
+}
@@ -6,53 +6,272 @@ SPDX-License-Identifier: Apache-2.0
 package observer
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
 	"github.com/trustbloc/sidetree-core-go/pkg/batch"
-	sidetreeobserver "github.com/trustbloc/sidetree-core-go/pkg/observer"
+	coreobserver "github.com/trustbloc/sidetree-core-go/pkg/observer"
 )
 
 var logger = logrus.New()
 
-type ledger struct {
+const defaultPollInterval = 500 * time.Millisecond
+
+// Observer polls the ledger for Sidetree transactions and processes them into the operation store.
+// The number of the last processed transaction is tracked by a cursor so that a restarted observer
+// resumes where it left off instead of re-reading the ledger from scratch.
+//
+// If the ledger reports a reorg then the operations of the orphaned transactions are rolled back and the
+// cursor is moved back so that the replacement transactions are processed.
+type Observer struct {
 	anchorWriter batch.AnchorWriter
+	pcp          protocol.ClientProvider
+	opStores     OperationStoreProvider
+	cursor       *Cursor
+	pollInterval time.Duration
+	subscribers  []func(sidetreeTxn txn.SidetreeTxn)
+	stopCh       chan struct{}
+	stopped      uint32
+	mutex        sync.Mutex
+}
+
+// notifier notifies subscribers when new transactions are available in the ledger
+type notifier interface {
+	Subscribe(subscriber func())
+}
+
+// OperationStoreProvider returns the operation store of a namespace
+type OperationStoreProvider interface {
+	ForNamespace(namespace string) (coreobserver.OperationStore, error)
+}
+
+// reorgNotifier notifies subscribers when transactions are orphaned by a ledger reorg
+type reorgNotifier interface {
+	SubscribeReorg(subscriber func(orphaned []*txn.SidetreeTxn))
+}
+
+// rollbacker removes the operations of orphaned transactions
+type rollbacker interface {
+	Rollback(transactionNumber uint64) error
+}
+
+// Option is an observer option
+type Option func(o *Observer)
+
+// WithCursor sets the cursor used to track processed transactions
+func WithCursor(cursor *Cursor) Option {
+	return func(o *Observer) {
+		o.cursor = cursor
+	}
+}
+
+// WithOperationStoreProvider sets the provider of the operation stores that are rolled back on a reorg
+func WithOperationStoreProvider(provider OperationStoreProvider) Option {
+	return func(o *Observer) {
+		o.opStores = provider
+	}
+}
+
+// WithPollInterval sets the interval at which the ledger is polled for new transactions
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *Observer) {
+		o.pollInterval = interval
+	}
+}
+
+// New returns a new observer that reads transactions from the given anchor writer
+func New(anchorWriter batch.AnchorWriter, pcp protocol.ClientProvider, opts ...Option) *Observer {
+	o := &Observer{
+		anchorWriter: anchorWriter,
+		pcp:          pcp,
+		cursor:       NewCursor(),
+		pollInterval: defaultPollInterval,
+		stopCh:       make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Subscribe registers a function that is invoked whenever a transaction was processed successfully. The function
+// is invoked while the observer processes transactions so it must not block.
+func (o *Observer) Subscribe(subscriber func(sidetreeTxn txn.SidetreeTxn)) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.subscribers = append(o.subscribers, subscriber)
+}
+
+// Start starts observer routines. If the anchor writer notifies subscribers of new transactions then
+// transactions are processed as soon as they become visible, otherwise they are picked up by the next poll.
+func (o *Observer) Start() {
+	if n, ok := o.anchorWriter.(notifier); ok {
+		n.Subscribe(func() {
+			if atomic.LoadUint32(&o.stopped) == 0 {
+				o.processAvailable()
+			}
+		})
+	}
+
+	if n, ok := o.anchorWriter.(reorgNotifier); ok {
+		n.SubscribeReorg(o.rollback)
+	}
+
+	go o.listen()
+}
+
+// Stop stops the observer
+func (o *Observer) Stop() {
+	atomic.StoreUint32(&o.stopped, 1)
+
+	o.stopCh <- struct{}{}
+}
+
+// Pause blocks until the observer has finished processing the current transactions and prevents it
+// from processing further transactions until Resume is called
+func (o *Observer) Pause() {
+	o.mutex.Lock()
+}
+
+// Resume resumes processing of transactions after a call to Pause
+func (o *Observer) Resume() {
+	o.mutex.Unlock()
+}
+
+func (o *Observer) listen() {
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.stopCh:
+			logger.Infof("The observer has been stopped. Exiting.")
+
+			return
+
+		case <-ticker.C:
+			o.processAvailable()
+		}
+	}
 }
 
-func (l *ledger) RegisterForSidetreeTxn() <-chan []txn.SidetreeTxn {
-	// TODO make it configurable
-	ticker := time.NewTicker(500 * time.Millisecond)
-	anchorFileAddressChan := make(chan []txn.SidetreeTxn, 100)
-	sinceTransactionNumber := -1
-	go func() {
-		for range ticker.C {
-			moreTransactions := true
-			sidetreeTxns := make([]txn.SidetreeTxn, 0)
-			for moreTransactions {
-				var sidetreeTxn *txn.SidetreeTxn
-				moreTransactions, sidetreeTxn = l.anchorWriter.Read(sinceTransactionNumber)
-				if sidetreeTxn != nil {
-					sinceTransactionNumber = int(sidetreeTxn.TransactionNumber)
-					logger.Debugf("found sidetree txn %d in ledger", sidetreeTxn.TransactionNumber)
-					sidetreeTxns = append(sidetreeTxns, *sidetreeTxn)
-				}
+// processAvailable processes all transactions that were added to the ledger since the last processed transaction
+func (o *Observer) processAvailable() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for {
+		moreTransactions, sidetreeTxn := o.anchorWriter.Read(o.cursor.Get())
+		if sidetreeTxn == nil {
+			return
+		}
+
+		logger.Debugf("found sidetree txn %d in ledger", sidetreeTxn.TransactionNumber)
+
+		// the cursor isn't moved past a transaction that failed so that it is processed again by the next poll
+		if err := o.process(*sidetreeTxn); err != nil {
+			logger.Warnf("Failed to process sidetree txn %d - will retry: %s", sidetreeTxn.TransactionNumber, err)
+
+			return
+		}
+
+		if err := o.cursor.Set(int(sidetreeTxn.TransactionNumber)); err != nil {
+			logger.Errorf("Failed to update observer cursor to transaction number %d: %s", sidetreeTxn.TransactionNumber, err)
+
+			return
+		}
+
+		if !moreTransactions {
+			return
+		}
+	}
+}
+
+// rollback removes the operations of the orphaned transactions from the operation stores and moves the cursor
+// back before the first orphaned transaction
+func (o *Observer) rollback(orphaned []*txn.SidetreeTxn) {
+	if len(orphaned) == 0 {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	first := orphaned[0].TransactionNumber
+
+	if o.opStores != nil {
+		namespaces := make(map[string]bool)
+
+		for _, t := range orphaned {
+			if namespaces[t.Namespace] {
+				continue
 			}
-			if len(sidetreeTxns) > 0 {
-				anchorFileAddressChan <- sidetreeTxns
+
+			namespaces[t.Namespace] = true
+
+			if err := o.rollbackNamespace(t.Namespace, first); err != nil {
+				logger.Errorf("Failed to roll back operations of namespace [%s] from transaction number %d: %s",
+					t.Namespace, first, err)
 			}
 		}
-	}()
-	return anchorFileAddressChan
+	}
+
+	if o.cursor.Get() >= int(first) {
+		if err := o.cursor.Set(int(first) - 1); err != nil {
+			logger.Errorf("Failed to move observer cursor back to transaction number %d: %s", int(first)-1, err)
+
+			return
+		}
+	}
+
+	logger.Infof("rolled back %d orphaned transactions starting at transaction number %d", len(orphaned), first)
+}
+
+func (o *Observer) rollbackNamespace(namespace string, transactionNumber uint64) error {
+	opStore, err := o.opStores.ForNamespace(namespace)
+	if err != nil {
+		return err
+	}
+
+	r, ok := opStore.(rollbacker)
+	if !ok {
+		logger.Warnf("Operation store for namespace [%s] does not support rollback", namespace)
+
+		return nil
+	}
+
+	return r.Rollback(transactionNumber)
 }
 
-// Start starts observer routines
-func Start(anchorWriter batch.AnchorWriter, pcp protocol.ClientProvider) {
-	providers := &sidetreeobserver.Providers{
-		Ledger:                 &ledger{anchorWriter: anchorWriter},
-		ProtocolClientProvider: pcp,
+func (o *Observer) process(sidetreeTxn txn.SidetreeTxn) error {
+	pc, err := o.pcp.ForNamespace(sidetreeTxn.Namespace)
+	if err != nil {
+		return fmt.Errorf("get protocol client for namespace [%s]: %w", sidetreeTxn.Namespace, err)
+	}
+
+	v, err := pc.Get(sidetreeTxn.ProtocolVersion)
+	if err != nil {
+		return fmt.Errorf("get processor for transaction time [%d]: %w", sidetreeTxn.ProtocolVersion, err)
+	}
+
+	_, err = v.TransactionProcessor().Process(sidetreeTxn)
+	if err != nil {
+		return fmt.Errorf("process anchor [%s]: %w", sidetreeTxn.AnchorString, err)
+	}
+
+	logger.Debugf("Successfully processed anchor[%s]", sidetreeTxn.AnchorString)
+
+	for _, subscriber := range o.subscribers {
+		subscriber(sidetreeTxn)
 	}
 
-	sidetreeobserver.New(providers).Start()
+	return nil
 }
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package observer
 
 import (
+	"errors"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	"sync"
 	"testing"
@@ -51,8 +52,10 @@ func TestStartObserver(t *testing.T) {
 
 		bcc := &mockAnchorWriter{
 			readValue: []*txn.SidetreeTxn{
-				{Namespace: mocks.DefaultNS, AnchorString: "1.anchorAddress", TransactionNumber: 0},
-				{Namespace: mocks.DefaultNS, AnchorString: "1.anchorAddress", TransactionNumber: 1}},
+				{Namespace: mocks.DefaultNS, AnchorString: "1.anchorAddress", TransactionNumber: 0,
+					CanonicalReference: "anchorAddress"},
+				{Namespace: mocks.DefaultNS, AnchorString: "1.anchorAddress", TransactionNumber: 1,
+					CanonicalReference: "anchorAddress"}},
 		}
 
 		casClient := mockCASClient{readFunc: func(key string) ([]byte, error) {
@@ -72,11 +75,21 @@ func TestStartObserver(t *testing.T) {
 			return nil, nil
 		}}
 
-		Start(bcc, mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithCasClient(casClient))
+		var processed []uint64
+
+		o := New(bcc, mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithCasClient(casClient))
+		o.Subscribe(func(sidetreeTxn txn.SidetreeTxn) {
+			rw.Lock()
+			defer rw.Unlock()
+
+			processed = append(processed, sidetreeTxn.TransactionNumber)
+		})
+		o.Start()
 
 		time.Sleep(2000 * time.Millisecond)
 
 		rw.RLock()
+		require.Equal(t, []uint64{0, 1}, processed)
 		require.Equal(t, 2, hits)
 		require.Equal(t, 2, len(txNum))
 		_, ok := txNum[0]
@@ -86,6 +99,149 @@ func TestStartObserver(t *testing.T) {
 		rw.RUnlock()
 
 	})
+
+	t.Run("resume from cursor", func(t *testing.T) {
+		var rw sync.RWMutex
+		txNum := make(map[uint64]*struct{}, 0)
+
+		opStore := &mockOperationStoreClient{
+			putFunc: func(ops []*operation.AnchoredOperation) error {
+				rw.Lock()
+				defer rw.Unlock()
+
+				for _, op := range ops {
+					txNum[op.TransactionNumber] = nil
+				}
+
+				return nil
+			},
+		}
+
+		bcc := NewAnchorWriter(mocks.DefaultNS)
+		require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+		require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+
+		cursor := NewCursor()
+		require.NoError(t, cursor.Set(0))
+
+		o := New(bcc, mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithCasClient(newMockCASClient()),
+			WithCursor(cursor))
+		o.Start()
+		defer o.Stop()
+
+		time.Sleep(2000 * time.Millisecond)
+
+		rw.RLock()
+		require.Equal(t, 1, len(txNum))
+		_, ok := txNum[1]
+		require.True(t, ok)
+		rw.RUnlock()
+
+		require.Equal(t, 1, cursor.Get())
+	})
+}
+
+func TestObserver_Notification(t *testing.T) {
+	var rw sync.RWMutex
+	hits := 0
+
+	opStore := &mockOperationStoreClient{
+		putFunc: func(ops []*operation.AnchoredOperation) error {
+			rw.Lock()
+			defer rw.Unlock()
+
+			hits += len(ops)
+
+			return nil
+		},
+	}
+
+	bcc := NewAnchorWriter(mocks.DefaultNS, WithSynchronousDelivery())
+
+	o := New(bcc, mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithCasClient(newMockCASClient()),
+		WithPollInterval(time.Hour))
+	o.Start()
+	defer o.Stop()
+
+	// the transaction is processed before WriteAnchor returns
+	require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+
+	rw.RLock()
+	require.Equal(t, 1, hits)
+	rw.RUnlock()
+}
+
+func TestObserver_Reorg(t *testing.T) {
+	opStore := mocks.NewMockOperationStore()
+
+	bcc := NewAnchorWriter(mocks.DefaultNS, WithSynchronousDelivery())
+	cursor := NewCursor()
+
+	o := New(bcc, mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithCasClient(newMockCASClient()),
+		WithCursor(cursor), WithPollInterval(time.Hour), WithOperationStoreProvider(mocks.NewMockOpStoreProvider(opStore)))
+	o.Start()
+	defer o.Stop()
+
+	require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+	require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+	require.Len(t, opStore.Operations(), 2)
+	require.Equal(t, 1, cursor.Get())
+
+	// the operations of the orphaned transaction are rolled back
+	_, _, err := bcc.Reorg(1, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, cursor.Get())
+
+	ops := opStore.Operations()
+	require.Len(t, ops, 1)
+	require.Equal(t, uint64(0), ops[0].TransactionNumber)
+
+	// the remaining transaction is orphaned and the replacement transactions are processed
+	_, _, err = bcc.Reorg(1, []*Anchor{{Anchor: "1.anchorAddress"}, {Anchor: "1.anchorAddress"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, cursor.Get())
+
+	ops = opStore.Operations()
+	require.Len(t, ops, 2)
+	require.Equal(t, uint64(2), ops[0].TransactionTime)
+	require.Equal(t, uint64(2), ops[1].TransactionTime)
+}
+
+func TestObserver_RetryFailedTransaction(t *testing.T) {
+	opStore := mocks.NewMockOperationStore()
+
+	// the first read of the core index file fails
+	failed := false
+	casClient := newMockCASClient()
+	readFunc := casClient.readFunc
+	casClient.readFunc = func(key string) ([]byte, error) {
+		if key == "anchorAddress" && !failed {
+			failed = true
+
+			return nil, errors.New("injected CAS error")
+		}
+
+		return readFunc(key)
+	}
+
+	bcc := NewAnchorWriter(mocks.DefaultNS, WithSynchronousDelivery())
+	cursor := NewCursor()
+
+	o := New(bcc, mocks.NewMockProtocolClientProvider().WithOpStore(opStore).WithCasClient(casClient),
+		WithCursor(cursor), WithPollInterval(time.Hour))
+	o.Start()
+	defer o.Stop()
+
+	// the cursor isn't moved past the failed transaction
+	require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+	require.True(t, failed)
+	require.Empty(t, opStore.Operations())
+	require.Equal(t, -1, cursor.Get())
+
+	// the failed transaction is processed again along with the next transaction
+	require.NoError(t, bcc.WriteAnchor("1.anchorAddress", nil, nil, 0))
+	require.Len(t, opStore.Operations(), 2)
+	require.Equal(t, 1, cursor.Get())
 }
 
 type mockAnchorWriter struct {
@@ -108,6 +264,25 @@ func (m mockAnchorWriter) Read(sinceTransactionNumber int) (bool, *txn.SidetreeT
 	return false, m.readValue[sinceTransactionNumber+1]
 }
 
+func newMockCASClient() mockCASClient {
+	return mockCASClient{readFunc: func(key string) ([]byte, error) {
+		if key == "anchorAddress" {
+			return compress(&models.CoreIndexFile{ProvisionalIndexFileURI: "provisionalIndexAddress",
+				Operations: &models.CoreOperations{
+					Create: []models.CreateReference{{
+						SuffixData: getSuffixData(),
+					}}}})
+		}
+		if key == "provisionalIndexAddress" {
+			return compress(&models.ProvisionalIndexFile{Chunks: []models.Chunk{{ChunkFileURI: "chunkAddress"}}})
+		}
+		if key == "chunkAddress" {
+			return compress(&models.ChunkFile{Deltas: []*model.DeltaModel{getDelta()}})
+		}
+		return nil, nil
+	}}
+}
+
 type mockCASClient struct {
 	readFunc func(key string) ([]byte, error)
 }
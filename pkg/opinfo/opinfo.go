@@ -0,0 +1,99 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package opinfo
+
+import (
+	"encoding/json"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+)
+
+// Operation describes an operation in the events and webhook notifications of the node. The transaction fields
+// are only set for operations that were processed into the operation store.
+type Operation struct {
+	DID                string         `json:"did"`
+	UniqueSuffix       string         `json:"uniqueSuffix"`
+	Type               operation.Type `json:"type,omitempty"`
+	TransactionTime    *uint64        `json:"transactionTime,omitempty"`
+	TransactionNumber  *uint64        `json:"transactionNumber,omitempty"`
+	CanonicalReference string         `json:"canonicalReference,omitempty"`
+}
+
+// Ledger holds the transactions that anchored the operations
+type Ledger interface {
+	Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn)
+}
+
+// NewQueued describes an operation that was queued by the batch writer
+func NewQueued(namespace string, op *operation.QueuedOperation) *Operation {
+	return &Operation{
+		DID:          DID(namespace, op.UniqueSuffix),
+		UniqueSuffix: op.UniqueSuffix,
+		Type:         Type(op.OperationRequest),
+	}
+}
+
+// NewAnchored describes an operation of a batch that was anchored by the batch writer
+func NewAnchored(namespace string, ref *operation.Reference) *Operation {
+	return &Operation{
+		DID:          DID(namespace, ref.UniqueSuffix),
+		UniqueSuffix: ref.UniqueSuffix,
+		Type:         ref.Type,
+	}
+}
+
+// NewProcessed describes an operation that was processed into the operation store. The canonical reference of its
+// transaction is taken from the ledger (if the ledger is set).
+func NewProcessed(namespace string, op *operation.AnchoredOperation, ledger Ledger) *Operation {
+	transactionTime := op.TransactionTime
+	transactionNumber := op.TransactionNumber
+
+	return &Operation{
+		DID:                DID(namespace, op.UniqueSuffix),
+		UniqueSuffix:       op.UniqueSuffix,
+		Type:               op.Type,
+		TransactionTime:    &transactionTime,
+		TransactionNumber:  &transactionNumber,
+		CanonicalReference: CanonicalReference(ledger, transactionNumber),
+	}
+}
+
+// Type returns the type of the given operation request. The type is only informational so an operation request
+// that can't be parsed has no type.
+func Type(request []byte) operation.Type {
+	r := &struct {
+		Type operation.Type `json:"type"`
+	}{}
+
+	if err := json.Unmarshal(request, r); err != nil {
+		return ""
+	}
+
+	return r.Type
+}
+
+// DID returns the short-form DID with the given unique suffix in the given namespace
+func DID(namespace, suffix string) string {
+	return namespace + ":" + suffix
+}
+
+// CanonicalReference returns the canonical reference of the transaction with the given number, which is the version
+// ID of the DID documents that were changed by the transaction. An empty string is returned if the ledger isn't set
+// or doesn't hold the transaction.
+func CanonicalReference(ledger Ledger, transactionNumber uint64) string {
+	if ledger == nil {
+		return ""
+	}
+
+	_, t := ledger.Read(int(transactionNumber) - 1)
+	if t == nil {
+		return ""
+	}
+
+	return t.CanonicalReference
+}
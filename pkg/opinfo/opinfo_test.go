@@ -0,0 +1,63 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package opinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+)
+
+func TestNewQueued(t *testing.T) {
+	op := NewQueued("did:sidetree", &operation.QueuedOperation{
+		UniqueSuffix:     "suffix",
+		OperationRequest: []byte(`{"type":"update"}`),
+	})
+	require.Equal(t, "did:sidetree:suffix", op.DID)
+	require.Equal(t, "suffix", op.UniqueSuffix)
+	require.Equal(t, operation.TypeUpdate, op.Type)
+	require.Nil(t, op.TransactionNumber)
+
+	// the type of a request that can't be parsed is unknown
+	op = NewQueued("did:sidetree", &operation.QueuedOperation{UniqueSuffix: "suffix", OperationRequest: []byte("{")})
+	require.Empty(t, op.Type)
+}
+
+func TestNewProcessed(t *testing.T) {
+	anchoredOp := &operation.AnchoredOperation{
+		Type:              operation.TypeCreate,
+		UniqueSuffix:      "suffix",
+		TransactionTime:   5,
+		TransactionNumber: 1,
+	}
+
+	op := NewProcessed("did:sidetree", anchoredOp, ledger{{TransactionNumber: 1, CanonicalReference: "ref"}})
+	require.Equal(t, "did:sidetree:suffix", op.DID)
+	require.Equal(t, operation.TypeCreate, op.Type)
+	require.Equal(t, uint64(5), *op.TransactionTime)
+	require.Equal(t, uint64(1), *op.TransactionNumber)
+	require.Equal(t, "ref", op.CanonicalReference)
+
+	op = NewProcessed("did:sidetree", anchoredOp, nil)
+	require.Empty(t, op.CanonicalReference)
+
+	op = NewProcessed("did:sidetree", anchoredOp, ledger{})
+	require.Empty(t, op.CanonicalReference)
+}
+
+// ledger holds the transactions in order of transaction number starting with 1
+type ledger []*txn.SidetreeTxn
+
+func (l ledger) Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn) {
+	if sinceTransactionNumber < 0 || sinceTransactionNumber >= len(l) {
+		return false, nil
+	}
+
+	return false, l[sinceTransactionNumber]
+}
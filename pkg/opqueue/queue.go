@@ -0,0 +1,278 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package opqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-mock/pkg/journal"
+)
+
+var logger = logrus.New()
+
+// Queue is a queue of the operations that are waiting to be batched. In addition to the operations the queue
+// records the time at which each operation was queued so that batches can be cut based on their age.
+type Queue struct {
+	items  []*Entry
+	nextID uint64
+	// removing is the number of removes that are neither acknowledged nor rolled back
+	removing int
+	journal  *journal.Journal
+	mutex    sync.RWMutex
+}
+
+// Entry is an operation in the queue
+type Entry struct {
+	// ID identifies the operation in the queue
+	ID         uint64                           `json:"id"`
+	Operation  *operation.QueuedOperationAtTime `json:"operation"`
+	QueuedTime time.Time                        `json:"queuedTime"`
+}
+
+// queueRecord is the journal record written when an operation is added (Added) and when operations are
+// removed (Removed)
+type queueRecord struct {
+	Added   *Entry   `json:"added,omitempty"`
+	Removed []uint64 `json:"removed,omitempty"`
+}
+
+// New returns a new, empty operation queue
+func New() *Queue {
+	return &Queue{}
+}
+
+// NewFileQueue returns an operation queue that persists operations to an append-only journal at the given path.
+// The operations that were added to the journal but never removed (i.e. that weren't anchored before the node
+// stopped) are loaded into the queue. The journal is discarded whenever the queue becomes empty.
+func NewFileQueue(path string) (*Queue, error) {
+	q := New()
+
+	j, err := journal.Open(path, func(data []byte) error {
+		record := &queueRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return err
+		}
+
+		if record.Added != nil {
+			q.items = append(q.items, record.Added)
+
+			if record.Added.ID > q.nextID {
+				q.nextID = record.Added.ID
+			}
+		}
+
+		q.remove(record.Removed)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open operation queue: %w", err)
+	}
+
+	q.journal = j
+
+	return q, nil
+}
+
+// Add adds the given operation to the tail of the queue and returns the new length of the queue
+func (q *Queue) Add(op *operation.QueuedOperation, protocolVersion uint64) (uint, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	e := &Entry{
+		ID: q.nextID + 1,
+		Operation: &operation.QueuedOperationAtTime{
+			QueuedOperation: *op,
+			ProtocolVersion: protocolVersion,
+		},
+		QueuedTime: time.Now(),
+	}
+
+	if q.journal != nil {
+		if err := q.journal.Append(&queueRecord{Added: e}); err != nil {
+			return 0, err
+		}
+	}
+
+	q.nextID = e.ID
+	q.items = append(q.items, e)
+
+	return uint(len(q.items)), nil
+}
+
+// Peek returns (up to) the given number of operations from the head of the queue but does not remove them
+func (q *Queue) Peek(num uint) (operation.QueuedOperationsAtTime, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return operations(q.items[:q.count(num)]), nil
+}
+
+// Remove removes (up to) the given number of operations from the head of the queue. The remove is committed
+// by calling ack and rolled back by calling nack, which puts the operations back at the head of the queue.
+// Until the remove is committed the operations remain in the journal so that they are loaded again if the
+// node stops before the operations are anchored.
+func (q *Queue) Remove(num uint) (ops operation.QueuedOperationsAtTime, ack func() uint, nack func(), err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	n := q.count(num)
+
+	removed := q.items[:n]
+	q.items = q.items[n:]
+	q.removing++
+
+	return operations(removed),
+		func() uint {
+			q.mutex.Lock()
+			defer q.mutex.Unlock()
+
+			q.removing--
+
+			if err := q.commitRemove(ids(removed), len(q.items)); err != nil {
+				logger.Errorf("Failed to record removal of %d operations in the operation queue journal - "+
+					"the operations will be queued again on restart: %s", len(removed), err)
+			}
+
+			return uint(len(q.items))
+		},
+		func() {
+			q.mutex.Lock()
+			defer q.mutex.Unlock()
+
+			q.removing--
+			q.items = append(append([]*Entry{}, removed...), q.items...)
+		}, nil
+}
+
+// Len returns the number of operations in the queue
+func (q *Queue) Len() uint {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return uint(len(q.items))
+}
+
+// OldestQueuedTime returns the time at which the operation at the head of the queue was queued.
+// False is returned if the queue is empty.
+func (q *Queue) OldestQueuedTime() (time.Time, bool) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if len(q.items) == 0 {
+		return time.Time{}, false
+	}
+
+	return q.items[0].QueuedTime, true
+}
+
+// Entries returns the operations in the queue, starting at the head of the queue
+func (q *Queue) Entries() []*Entry {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	entries := make([]*Entry, len(q.items))
+	copy(entries, q.items)
+
+	return entries
+}
+
+// RemoveEntry removes the operation with the given ID from the queue. False is returned if the queue doesn't
+// contain the operation.
+func (q *Queue) RemoveEntry(id uint64) (bool, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, e := range q.items {
+		if e.ID == id {
+			remaining := append(q.items[:i:i], q.items[i+1:]...)
+
+			if err := q.commitRemove([]uint64{id}, len(remaining)); err != nil {
+				return false, err
+			}
+
+			q.items = remaining
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// commitRemove records the removal of the operations with the given IDs in the journal. The journal is
+// discarded instead if no operations remain in the queue.
+func (q *Queue) commitRemove(removed []uint64, remaining int) error {
+	if q.journal == nil {
+		return nil
+	}
+
+	if remaining == 0 && q.removing == 0 {
+		return q.journal.Reset()
+	}
+
+	return q.journal.Append(&queueRecord{Removed: removed})
+}
+
+// remove removes the operations with the given IDs from the queue
+func (q *Queue) remove(removed []uint64) {
+	if len(removed) == 0 {
+		return
+	}
+
+	remaining := make([]*Entry, 0, len(q.items))
+
+	for _, e := range q.items {
+		if !contains(removed, e.ID) {
+			remaining = append(remaining, e)
+		}
+	}
+
+	q.items = remaining
+}
+
+func (q *Queue) count(num uint) int {
+	if int(num) > len(q.items) {
+		return len(q.items)
+	}
+
+	return int(num)
+}
+
+func operations(items []*Entry) operation.QueuedOperationsAtTime {
+	ops := make(operation.QueuedOperationsAtTime, len(items))
+	for i, e := range items {
+		ops[i] = e.Operation
+	}
+
+	return ops
+}
+
+func ids(entries []*Entry) []uint64 {
+	result := make([]uint64, len(entries))
+	for i, e := range entries {
+		result[i] = e.ID
+	}
+
+	return result
+}
+
+func contains(ids []uint64, id uint64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package opqueue
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+)
+
+func TestQueue(t *testing.T) {
+	q := New()
+
+	_, ok := q.OldestQueuedTime()
+	require.False(t, ok)
+
+	n, err := q.Add(&operation.QueuedOperation{UniqueSuffix: "suffix1"}, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint(1), n)
+
+	first, ok := q.OldestQueuedTime()
+	require.True(t, ok)
+
+	n, err = q.Add(&operation.QueuedOperation{UniqueSuffix: "suffix2"}, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), n)
+
+	ops, err := q.Peek(5)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	require.Equal(t, "suffix1", ops[0].UniqueSuffix)
+	require.Equal(t, uint64(10), ops[1].ProtocolVersion)
+
+	t.Run("remove and nack", func(t *testing.T) {
+		ops, _, nack, err := q.Remove(1)
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, uint(1), q.Len())
+
+		nack()
+
+		// the operation is put back at the head of the queue with its original queued time
+		require.Equal(t, uint(2), q.Len())
+
+		oldest, ok := q.OldestQueuedTime()
+		require.True(t, ok)
+		require.Equal(t, first, oldest)
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		entries := q.Entries()
+		require.Len(t, entries, 2)
+		require.Equal(t, uint64(1), entries[0].ID)
+		require.Equal(t, first, entries[0].QueuedTime)
+		require.Equal(t, "suffix2", entries[1].Operation.UniqueSuffix)
+	})
+
+	t.Run("remove and ack", func(t *testing.T) {
+		ops, ack, _, err := q.Remove(1)
+		require.NoError(t, err)
+		require.Equal(t, "suffix1", ops[0].UniqueSuffix)
+		require.Equal(t, uint(1), ack())
+
+		ops, err = q.Peek(1)
+		require.NoError(t, err)
+		require.Equal(t, "suffix2", ops[0].UniqueSuffix)
+	})
+
+	t.Run("remove entry", func(t *testing.T) {
+		_, err := q.Add(&operation.QueuedOperation{UniqueSuffix: "suffix3"}, 0)
+		require.NoError(t, err)
+
+		removed, err := q.RemoveEntry(2)
+		require.NoError(t, err)
+		require.True(t, removed)
+
+		entries := q.Entries()
+		require.Len(t, entries, 1)
+		require.Equal(t, "suffix3", entries[0].Operation.UniqueSuffix)
+
+		removed, err = q.RemoveEntry(2)
+		require.NoError(t, err)
+		require.False(t, removed)
+	})
+}
+
+func TestFileQueue(t *testing.T) {
+	t.Run("unanchored operations are replayed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "opqueue")
+
+		q, err := NewFileQueue(path)
+		require.NoError(t, err)
+
+		for _, suffix := range []string{"suffix1", "suffix2", "suffix3", "suffix4"} {
+			_, err = q.Add(&operation.QueuedOperation{UniqueSuffix: suffix, OperationRequest: []byte(suffix)}, 10)
+			require.NoError(t, err)
+		}
+
+		// suffix1 is anchored, suffix2 is being anchored when the node stops and suffix3 is removed by an admin
+		_, ack, _, err := q.Remove(1)
+		require.NoError(t, err)
+		ack()
+
+		_, _, _, err = q.Remove(1)
+		require.NoError(t, err)
+
+		removed, err := q.RemoveEntry(3)
+		require.NoError(t, err)
+		require.True(t, removed)
+
+		q, err = NewFileQueue(path)
+		require.NoError(t, err)
+
+		entries := q.Entries()
+		require.Len(t, entries, 2)
+		require.Equal(t, "suffix2", entries[0].Operation.UniqueSuffix)
+		require.Equal(t, []byte("suffix2"), entries[0].Operation.OperationRequest)
+		require.Equal(t, uint64(10), entries[0].Operation.ProtocolVersion)
+		require.Equal(t, "suffix4", entries[1].Operation.UniqueSuffix)
+
+		// IDs of replayed operations aren't reused
+		_, err = q.Add(&operation.QueuedOperation{UniqueSuffix: "suffix5"}, 10)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), q.Entries()[2].ID)
+	})
+
+	t.Run("journal is discarded when queue is empty", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "opqueue")
+
+		q, err := NewFileQueue(path)
+		require.NoError(t, err)
+
+		_, err = q.Add(&operation.QueuedOperation{UniqueSuffix: "suffix1"}, 0)
+		require.NoError(t, err)
+
+		_, ack, _, err := q.Remove(1)
+		require.NoError(t, err)
+		require.Zero(t, ack())
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+
+		q, err = NewFileQueue(path)
+		require.NoError(t, err)
+		require.Zero(t, q.Len())
+	})
+
+	t.Run("invalid journal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "opqueue")
+		require.NoError(t, ioutil.WriteFile(path, []byte("invalid\n"), 0o600))
+
+		_, err := NewFileQueue(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open operation queue")
+	})
+}
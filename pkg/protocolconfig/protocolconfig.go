@@ -0,0 +1,214 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package protocolconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/compression"
+	"github.com/trustbloc/sidetree-core-go/pkg/hashing"
+	"github.com/trustbloc/sidetree-core-go/pkg/patch"
+
+	"github.com/trustbloc/sidetree-mock/pkg/fileutil"
+)
+
+// Load loads the protocol versions from the YAML or JSON file at the given path. The file contains a list of
+// protocol versions in the format accepted by Parse.
+func Load(path string) ([]protocol.Protocol, error) {
+	data, err := fileutil.ReadYAMLAsJSON(path)
+	if err != nil {
+		return nil, fmt.Errorf("load protocol file: %w", err)
+	}
+
+	protocols, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("protocol file [%s]: %w", path, err)
+	}
+
+	return protocols, nil
+}
+
+// Parse parses a JSON array of protocol versions. Each protocol version must specify every protocol parameter
+// (nothing is inherited from the previous protocol version or from defaults so that a parameter that is left out
+// by mistake is reported rather than silently taken from elsewhere), e.g.
+//
+//  [{"genesisTime": 0, "multihashAlgorithms": [18], "maxOperationCount": 10, ...}]
+//
+// The resulting protocol versions are validated.
+func Parse(data []byte) ([]protocol.Protocol, error) {
+	var versions []json.RawMessage
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("parse protocol versions: %w", err)
+	}
+
+	protocols := make([]protocol.Protocol, 0, len(versions))
+
+	for i, v := range versions {
+		p, err := parseVersion(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse protocol version %d: %w", i, err)
+		}
+
+		protocols = append(protocols, p)
+	}
+
+	if err := Validate(protocols); err != nil {
+		return nil, err
+	}
+
+	return protocols, nil
+}
+
+// Validate checks that the given protocol versions are ordered by genesis time, that the first protocol version
+// starts at genesis time 0 and that the parameters of each protocol version are consistent.
+func Validate(protocols []protocol.Protocol) error {
+	if len(protocols) == 0 {
+		return fmt.Errorf("at least one protocol version is required")
+	}
+
+	var errs []string
+
+	if protocols[0].GenesisTime != 0 {
+		errs = append(errs, fmt.Sprintf("genesis time of the first protocol version must be 0 but is %d",
+			protocols[0].GenesisTime))
+	}
+
+	for i, p := range protocols {
+		if i > 0 && p.GenesisTime <= protocols[i-1].GenesisTime {
+			errs = append(errs, fmt.Sprintf("protocol version %d: genesis time %d must be greater than genesis time %d "+
+				"of the previous protocol version", i, p.GenesisTime, protocols[i-1].GenesisTime))
+		}
+
+		for _, e := range validateProtocol(p) {
+			errs = append(errs, fmt.Sprintf("protocol version %d (genesis time %d): %s", i, p.GenesisTime, e))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid protocol versions: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func validateProtocol(p protocol.Protocol) []string { //nolint:gocyclo
+	var errs []string
+
+	required := []struct {
+		name  string
+		value uint
+	}{
+		{"maxOperationCount", p.MaxOperationCount},
+		{"maxOperationSize", p.MaxOperationSize},
+		{"maxOperationHashLength", p.MaxOperationHashLength},
+		{"maxDeltaSize", p.MaxDeltaSize},
+		{"maxCasUriLength", p.MaxCasURILength},
+		{"maxCoreIndexFileSize", p.MaxCoreIndexFileSize},
+		{"maxProofFileSize", p.MaxProofFileSize},
+		{"maxProvisionalIndexFileSize", p.MaxProvisionalIndexFileSize},
+		{"maxChunkFileSize", p.MaxChunkFileSize},
+		{"maxMemoryDecompressionFactor", p.MaxMemoryDecompressionFactor},
+	}
+
+	for _, r := range required {
+		if r.value == 0 {
+			errs = append(errs, fmt.Sprintf("%s must be greater than 0", r.name))
+		}
+	}
+
+	if p.MaxOperationSize <= p.MaxDeltaSize {
+		errs = append(errs, fmt.Sprintf("maxOperationSize [%d] must be greater than maxDeltaSize [%d]",
+			p.MaxOperationSize, p.MaxDeltaSize))
+	}
+
+	if p.MaxChunkFileSize < p.MaxDeltaSize {
+		errs = append(errs, fmt.Sprintf("maxChunkFileSize [%d] must not be smaller than maxDeltaSize [%d]",
+			p.MaxChunkFileSize, p.MaxDeltaSize))
+	}
+
+	if len(p.MultihashAlgorithms) == 0 {
+		errs = append(errs, "at least one multihash algorithm is required")
+	}
+
+	for _, alg := range p.MultihashAlgorithms {
+		if _, err := hashing.GetHashFromMultihash(alg); err != nil {
+			errs = append(errs, fmt.Sprintf("multihash algorithm [%d] is not supported", alg))
+		}
+	}
+
+	if _, err := compression.New(compression.WithDefaultAlgorithms()).Compress(p.CompressionAlgorithm, nil); err != nil {
+		errs = append(errs, fmt.Sprintf("compression algorithm [%s] is not supported", p.CompressionAlgorithm))
+	}
+
+	for _, action := range p.Patches {
+		if _, err := (patch.Patch{patch.ActionKey: action}).GetAction(); err != nil {
+			errs = append(errs, fmt.Sprintf("patch [%s] is not supported", action))
+		}
+	}
+
+	if len(p.SignatureAlgorithms) == 0 {
+		errs = append(errs, "at least one signature algorithm is required")
+	}
+
+	if len(p.KeyAlgorithms) == 0 {
+		errs = append(errs, "at least one key algorithm is required")
+	}
+
+	return errs
+}
+
+// parseVersion parses a protocol version. Unknown and missing parameters are rejected.
+func parseVersion(data []byte) (protocol.Protocol, error) {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return protocol.Protocol{}, err
+	}
+
+	var missing []string
+
+	for _, name := range parameters() {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return protocol.Protocol{}, fmt.Errorf("missing parameters: %s", strings.Join(missing, ", "))
+	}
+
+	p := protocol.Protocol{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&p); err != nil {
+		return protocol.Protocol{}, err
+	}
+
+	return p, nil
+}
+
+// parameters returns the JSON names of the protocol parameters
+func parameters() []string {
+	t := reflect.TypeOf(protocol.Protocol{})
+
+	names := make([]string, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,229 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package protocolconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		protocols, err := Parse(versions(t,
+			version(t, nil),
+			version(t, map[string]interface{}{"genesisTime": 100, "maxOperationCount": 10, "multihashAlgorithms": []uint{19}}),
+			version(t, map[string]interface{}{"genesisTime": 200, "patches": []string{"replace", "ietf-json-patch"}}),
+		))
+		require.NoError(t, err)
+		require.Len(t, protocols, 3)
+
+		require.Equal(t, mocks.DefaultProtocol(), protocols[0])
+
+		require.Equal(t, uint64(100), protocols[1].GenesisTime)
+		require.Equal(t, uint(10), protocols[1].MaxOperationCount)
+		require.Equal(t, []uint{19}, protocols[1].MultihashAlgorithms)
+
+		// nothing is inherited from the previous protocol version
+		require.Equal(t, uint64(200), protocols[2].GenesisTime)
+		require.Equal(t, mocks.DefaultProtocol().MaxOperationCount, protocols[2].MaxOperationCount)
+		require.Equal(t, mocks.DefaultProtocol().MultihashAlgorithms, protocols[2].MultihashAlgorithms)
+		require.Equal(t, []string{"replace", "ietf-json-patch"}, protocols[2].Patches)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := Parse([]byte(`{`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse protocol versions")
+	})
+
+	t.Run("invalid protocol version", func(t *testing.T) {
+		_, err := Parse(versions(t, version(t, nil), version(t, map[string]interface{}{"maxOperationCount": "ten"})))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse protocol version 1")
+	})
+
+	t.Run("missing parameters", func(t *testing.T) {
+		v := version(t, map[string]interface{}{"genesisTime": 100})
+		delete(v, "maxOperationCount")
+		delete(v, "nonceSize")
+
+		_, err := Parse(versions(t, version(t, nil), v))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse protocol version 1: missing parameters: maxOperationCount, nonceSize")
+
+		_, err = Parse([]byte(`[{"genesisTime": 0}]`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing parameters: multihashAlgorithms, maxOperationCount")
+	})
+
+	t.Run("unknown parameter", func(t *testing.T) {
+		_, err := Parse(versions(t, version(t, map[string]interface{}{"maxOperationCnt": 10})))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "maxOperationCnt"`)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("no protocol versions", func(t *testing.T) {
+		_, err := Parse([]byte(`[]`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one protocol version is required")
+	})
+
+	t.Run("genesis times", func(t *testing.T) {
+		_, err := Parse(versions(t,
+			version(t, map[string]interface{}{"genesisTime": 10}),
+			version(t, map[string]interface{}{"genesisTime": 5}),
+		))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "genesis time of the first protocol version must be 0 but is 10")
+		require.Contains(t, err.Error(),
+			"protocol version 1: genesis time 5 must be greater than genesis time 10 of the previous protocol version")
+	})
+
+	t.Run("inconsistent sizes", func(t *testing.T) {
+		_, err := Parse(versions(t, version(t, map[string]interface{}{
+			"maxOperationSize": 1000, "maxDeltaSize": 1800, "maxChunkFileSize": 1500,
+		})))
+		require.Error(t, err)
+		require.Contains(t, err.Error(),
+			"protocol version 0 (genesis time 0): maxOperationSize [1000] must be greater than maxDeltaSize [1800]")
+		require.Contains(t, err.Error(), "maxChunkFileSize [1500] must not be smaller than maxDeltaSize [1800]")
+	})
+
+	t.Run("zero values", func(t *testing.T) {
+		_, err := Parse(versions(t, version(t, map[string]interface{}{"maxOperationCount": 0, "maxCasUriLength": 0})))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "maxOperationCount must be greater than 0")
+		require.Contains(t, err.Error(), "maxCasUriLength must be greater than 0")
+	})
+
+	t.Run("unsupported algorithms", func(t *testing.T) {
+		_, err := Parse(versions(t, version(t, map[string]interface{}{
+			"multihashAlgorithms":  []uint{22},
+			"compressionAlgorithm": "ZIP",
+			"patches":              []string{"replace", "add-keys"},
+			"signatureAlgorithms":  []string{},
+			"keyAlgorithms":        []string{},
+		})))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "multihash algorithm [22] is not supported")
+		require.Contains(t, err.Error(), "compression algorithm [ZIP] is not supported")
+		require.Contains(t, err.Error(), "patch [add-keys] is not supported")
+		require.Contains(t, err.Error(), "at least one signature algorithm is required")
+		require.Contains(t, err.Error(), "at least one key algorithm is required")
+	})
+
+	t.Run("no multihash algorithms", func(t *testing.T) {
+		_, err := Parse(versions(t, version(t, map[string]interface{}{"multihashAlgorithms": []uint{}})))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one multihash algorithm is required")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("YAML", func(t *testing.T) {
+		path := filepath.Join(dir, "protocol.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`
+- genesisTime: 0
+  multihashAlgorithms: [18]
+  maxOperationCount: 1
+  maxOperationSize: 2500
+  maxOperationHashLength: 100
+  maxDeltaSize: 1800
+  maxCasUriLength: 100
+  compressionAlgorithm: GZIP
+  maxCoreIndexFileSize: 1000000
+  maxProofFileSize: 2500000
+  maxProvisionalIndexFileSize: 1000000
+  maxChunkFileSize: 10000000
+  patches: [replace, add-public-keys, remove-public-keys, add-services, remove-services, ietf-json-patch]
+  signatureAlgorithms: [EdDSA, ES256, ES256K]
+  keyAlgorithms: [Ed25519, P-256, secp256k1]
+  maxOperationTimeDelta: 0
+  nonceSize: 0
+  maxMemoryDecompressionFactor: 3
+`), 0o600))
+
+		protocols, err := Load(path)
+		require.NoError(t, err)
+		require.Len(t, protocols, 1)
+		require.Equal(t, uint(1), protocols[0].MaxOperationCount)
+		require.Equal(t, []uint{18}, protocols[0].MultihashAlgorithms)
+		require.Equal(t, []string{"Ed25519", "P-256", "secp256k1"}, protocols[0].KeyAlgorithms)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		path := filepath.Join(dir, "protocol.json")
+		require.NoError(t, ioutil.WriteFile(path, versions(t, version(t, map[string]interface{}{"maxDeltaSize": 1000})),
+			0o600))
+
+		protocols, err := Load(path)
+		require.NoError(t, err)
+		require.Len(t, protocols, 1)
+		require.Equal(t, uint(1000), protocols[0].MaxDeltaSize)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := Load(filepath.Join(dir, "missing.yaml"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "load protocol file")
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte("- genesisTime: [0"), 0o600))
+
+		_, err := Load(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse file")
+	})
+
+	t.Run("invalid protocol", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid-protocol.yaml")
+		require.NoError(t, ioutil.WriteFile(path, versions(t, version(t, map[string]interface{}{"maxOperationSize": 10})),
+			0o600))
+
+		_, err := Load(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "maxOperationSize [10] must be greater than maxDeltaSize [1800]")
+	})
+}
+
+// version returns the parameters of the default protocol with the given parameters replaced
+func version(t *testing.T, params map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(mocks.DefaultProtocol())
+	require.NoError(t, err)
+
+	v := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(data, &v))
+
+	for name, value := range params {
+		v[name] = value
+	}
+
+	return v
+}
+
+func versions(t *testing.T, v ...map[string]interface{}) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return data
+}
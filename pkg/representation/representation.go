@@ -0,0 +1,302 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package representation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+)
+
+var logger = log.New("representation")
+
+// Content types of the DID resolution result and of the representations of a DID document.
+const (
+	// ResolutionResult is the content type of the DID resolution result (DID document and metadata).
+	ResolutionResult = `application/ld+json;profile="https://w3id.org/did-resolution"`
+	// JSON is the content type of the DID resolution result as plain JSON.
+	JSON = "application/json"
+	// DIDJSON is the content type of the JSON representation of a DID document (without @context).
+	DIDJSON = "application/did+json"
+	// DIDLDJSON is the content type of the JSON-LD representation of a DID document.
+	DIDLDJSON = "application/did+ld+json"
+	// DIDCBOR is the content type of the CBOR representation of a DID document (without @context).
+	DIDCBOR = "application/did+cbor"
+)
+
+// didContext is the context that is added to the JSON-LD representation of a DID document without context
+const didContext = "https://www.w3.org/ns/did/v1"
+
+// Negotiate returns the supported content type that best matches the given Accept header. The first supported
+// content type is returned if the Accept header is empty. False is returned if no supported content type is
+// acceptable.
+//
+// A supported content type with a profile (e.g. the resolution result) only matches media ranges that list the
+// profile, so a wildcard media range (e.g. "*/*") never matches it.
+func Negotiate(accept string, supported ...string) (string, bool) {
+	if len(supported) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return supported[0], true
+	}
+
+	for _, r := range parseAccept(accept) {
+		for _, contentType := range supported {
+			if r.matches(contentType) {
+				return contentType, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Explicit returns true if a media range of the given Accept header that isn't a wildcard matches one of the
+// supported content types. Clients that send only wildcard media ranges (e.g. the default "*/*" of most HTTP
+// clients) have no preference and should get the same response as clients that send no Accept header.
+func Explicit(accept string, supported ...string) bool {
+	for _, r := range parseAccept(accept) {
+		if r.wildcard() {
+			continue
+		}
+
+		for _, contentType := range supported {
+			if r.matches(contentType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Encode returns the given resolution result in the representation with the given content type.
+func Encode(contentType string, result *document.ResolutionResult) ([]byte, error) {
+	switch contentType {
+	case ResolutionResult, JSON:
+		return json.Marshal(result)
+	case DIDLDJSON:
+		doc := copyDocument(result.Document)
+		if _, ok := doc[document.ContextProperty]; !ok {
+			doc[document.ContextProperty] = []interface{}{didContext}
+		}
+
+		return json.Marshal(doc)
+	case DIDJSON:
+		doc := copyDocument(result.Document)
+		delete(doc, document.ContextProperty)
+
+		return json.Marshal(doc)
+	case DIDCBOR:
+		doc := copyDocument(result.Document)
+		delete(doc, document.ContextProperty)
+
+		em, err := cbor.CoreDetEncOptions().EncMode()
+		if err != nil {
+			return nil, err
+		}
+
+		return em.Marshal(map[string]interface{}(doc))
+	default:
+		return nil, fmt.Errorf("content type [%s] is not supported", contentType)
+	}
+}
+
+// NewHandler returns a handler that serves the resolution result of the given resolve handler in the representation
+// requested by the Accept header of the request: the resolution result (ResolutionResult or JSON) or the DID
+// document only (DIDJSON, DIDLDJSON or DIDCBOR). If the request has no Accept header or only wildcard media ranges
+// then the response of the resolve handler is returned as is. 406 is returned if none of the representations is
+// acceptable.
+func NewHandler(resolveHandler common.HTTPRequestHandler) common.HTTPRequestHandler {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		accept := req.Header.Get("Accept")
+		if strings.TrimSpace(accept) == "" {
+			resolveHandler(rw, req)
+
+			return
+		}
+
+		supported := []string{ResolutionResult, JSON, DIDLDJSON, DIDJSON, DIDCBOR}
+
+		contentType, ok := Negotiate(accept, supported...)
+		if !ok {
+			common.WriteError(rw, http.StatusNotAcceptable,
+				fmt.Errorf("none of the representations in [%s] is supported", accept))
+
+			return
+		}
+
+		if !Explicit(accept, supported...) {
+			resolveHandler(rw, req)
+
+			return
+		}
+
+		resp := NewResponseBuffer()
+
+		resolveHandler(resp, req)
+
+		// errors are returned as is
+		if resp.Status != http.StatusOK {
+			resp.CopyTo(rw)
+
+			return
+		}
+
+		result := &document.ResolutionResult{}
+		if err := json.Unmarshal(resp.Body.Bytes(), result); err != nil {
+			common.WriteError(rw, http.StatusInternalServerError, fmt.Errorf("unmarshal resolution result: %w", err))
+
+			return
+		}
+
+		body, err := Encode(contentType, result)
+		if err != nil {
+			common.WriteError(rw, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		rw.Header().Set("Content-Type", contentType)
+		rw.WriteHeader(http.StatusOK)
+
+		if _, err := rw.Write(body); err != nil {
+			logger.Errorf("Unable to write response: %s", err)
+		}
+	}
+}
+
+func copyDocument(doc document.Document) document.Document {
+	c := make(document.Document, len(doc))
+	for k, v := range doc {
+		c[k] = v
+	}
+
+	return c
+}
+
+type mediaRange struct {
+	mediaType string
+	profiles  []string
+	q         float64
+}
+
+// parseAccept returns the media ranges of the given Accept header ordered by preference. Invalid media ranges and
+// media ranges with quality zero are ignored.
+func parseAccept(accept string) []*mediaRange {
+	var ranges []*mediaRange
+
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q <= 0 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, &mediaRange{mediaType: mediaType, profiles: strings.Fields(params["profile"]), q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	return ranges
+}
+
+// matches returns true if the given content type is within the media range
+func (r *mediaRange) matches(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case r.mediaType == "*/*":
+	case r.wildcard():
+		if !strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*")) {
+			return false
+		}
+	case r.mediaType != mediaType:
+		return false
+	}
+
+	profile, ok := params["profile"]
+	if !ok {
+		return true
+	}
+
+	for _, p := range r.profiles {
+		if p == profile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wildcard returns true if the media range matches any type (*/*) or any subtype (e.g. application/*)
+func (r *mediaRange) wildcard() bool {
+	return strings.HasSuffix(r.mediaType, "/*")
+}
+
+// ResponseBuffer buffers the response of a handler so that the response may be inspected and changed before it is
+// copied to the actual response writer. The status is 200 unless the handler writes another status.
+type ResponseBuffer struct {
+	Status int
+	Body   bytes.Buffer
+	header http.Header
+}
+
+// NewResponseBuffer returns a new response buffer
+func NewResponseBuffer() *ResponseBuffer {
+	return &ResponseBuffer{Status: http.StatusOK, header: make(http.Header)}
+}
+
+// Header returns the header of the buffered response
+func (b *ResponseBuffer) Header() http.Header {
+	return b.header
+}
+
+// Write writes the given data to the body of the buffered response
+func (b *ResponseBuffer) Write(data []byte) (int, error) {
+	return b.Body.Write(data)
+}
+
+// WriteHeader sets the status of the buffered response
+func (b *ResponseBuffer) WriteHeader(status int) {
+	b.Status = status
+}
+
+// CopyTo writes the buffered response to the given response writer
+func (b *ResponseBuffer) CopyTo(rw http.ResponseWriter) {
+	for k, v := range b.header {
+		rw.Header()[k] = v
+	}
+
+	rw.WriteHeader(b.Status)
+
+	if _, err := rw.Write(b.Body.Bytes()); err != nil {
+		logger.Errorf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package representation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+)
+
+const did = "did:sidetree:EiDahaOGH-liLLdDtTxEAdc8i-cfCz-WUcQdRJheMVNn3A"
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{ResolutionResult, DIDLDJSON, DIDJSON}
+
+	for accept, expected := range map[string]string{
+		"":                                   ResolutionResult,
+		"*/*":                                DIDLDJSON,
+		"application/did+json":               DIDJSON,
+		"application/*":                      DIDLDJSON,
+		"text/html, application/did+ld+json": DIDLDJSON,
+		`application/ld+json;profile="https://w3id.org/did-resolution"`:                     ResolutionResult,
+		`application/ld+json;profile="https://example.com https://w3id.org/did-resolution"`: ResolutionResult,
+		"application/did+json;q=0.5, application/did+ld+json":                               DIDLDJSON,
+		"application/did+json;q=0.5, application/did+ld+json;q=0.4":                         DIDJSON,
+		"application/did+json;q=0.5, application/did+ld+json;q=0, */*;q=0.1":                DIDJSON,
+		"application/did+json;q=invalid, application/did+ld+json":                           DIDLDJSON,
+	} {
+		contentType, ok := Negotiate(accept, supported...)
+		require.True(t, ok, accept)
+		require.Equal(t, expected, contentType, accept)
+	}
+
+	for _, accept := range []string{
+		"text/html",
+		"application/ld+json",
+		`application/ld+json;profile="https://example.com"`,
+		"application/did+cbor",
+		"application/did+json;q=0",
+		"invalid",
+	} {
+		_, ok := Negotiate(accept, supported...)
+		require.False(t, ok, accept)
+	}
+
+	_, ok := Negotiate("*/*")
+	require.False(t, ok)
+
+	// the profiled resolution result isn't matched by wildcards
+	_, ok = Negotiate("*/*, application/*", ResolutionResult)
+	require.False(t, ok)
+}
+
+func TestExplicit(t *testing.T) {
+	supported := []string{ResolutionResult, DIDLDJSON, DIDJSON}
+
+	require.True(t, Explicit(DIDJSON, supported...))
+	require.True(t, Explicit("*/*, application/did+json;q=0.5", supported...))
+	require.True(t, Explicit(ResolutionResult, supported...))
+
+	require.False(t, Explicit("", supported...))
+	require.False(t, Explicit("*/*", supported...))
+	require.False(t, Explicit("text/html, application/*;q=0.5", supported...))
+	require.False(t, Explicit("application/ld+json", supported...))
+}
+
+func TestEncode(t *testing.T) {
+	result := &document.ResolutionResult{
+		Context: "https://w3id.org/did-resolution/v1",
+		Document: document.Document{
+			"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+			"id":       did,
+		},
+		DocumentMetadata: document.Metadata{"canonicalId": did},
+	}
+
+	t.Run("resolution result", func(t *testing.T) {
+		for _, contentType := range []string{ResolutionResult, JSON} {
+			body, err := Encode(contentType, result)
+			require.NoError(t, err)
+
+			decoded := &document.ResolutionResult{}
+			require.NoError(t, json.Unmarshal(body, decoded))
+			require.Equal(t, did, decoded.Document.ID())
+			require.Equal(t, did, decoded.DocumentMetadata["canonicalId"])
+		}
+	})
+
+	t.Run("DID document", func(t *testing.T) {
+		body, err := Encode(DIDLDJSON, result)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"@context":["https://www.w3.org/ns/did/v1"],"id":"`+did+`"}`, string(body))
+
+		body, err = Encode(DIDLDJSON, &document.ResolutionResult{Document: document.Document{"id": did}})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"@context":["https://www.w3.org/ns/did/v1"],"id":"`+did+`"}`, string(body))
+
+		body, err = Encode(DIDJSON, result)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"`+did+`"}`, string(body))
+
+		// the resolution result is not modified
+		require.NotEmpty(t, result.Document.Context())
+	})
+
+	t.Run("CBOR", func(t *testing.T) {
+		body, err := Encode(DIDCBOR, result)
+		require.NoError(t, err)
+
+		doc := make(map[string]interface{})
+		require.NoError(t, cbor.Unmarshal(body, &doc))
+		require.Equal(t, map[string]interface{}{"id": did}, doc)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		_, err := Encode("text/html", result)
+		require.EqualError(t, err, "content type [text/html] is not supported")
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	resolveHandler := func(rw http.ResponseWriter, req *http.Request) {
+		common.WriteResponse(rw, http.StatusOK, &document.ResolutionResult{
+			Context: "https://w3id.org/did-resolution/v1",
+			Document: document.Document{
+				"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+				"id":       did,
+			},
+			DocumentMetadata: document.Metadata{"canonicalId": did},
+		})
+	}
+
+	handler := NewHandler(resolveHandler)
+
+	t.Run("no Accept header", func(t *testing.T) {
+		rr := serveHTTP(handler, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		// the response of the resolve handler is returned as is
+		expected := serveHTTP(resolveHandler, "")
+		require.Equal(t, expected.Header().Get("Content-Type"), rr.Header().Get("Content-Type"))
+		require.Equal(t, expected.Body.String(), rr.Body.String())
+	})
+
+	t.Run("wildcard Accept header", func(t *testing.T) {
+		expected := serveHTTP(resolveHandler, "")
+
+		for _, accept := range []string{"*/*", "application/*", "text/html, */*;q=0.8"} {
+			rr := serveHTTP(handler, accept)
+			require.Equal(t, http.StatusOK, rr.Code, accept)
+
+			// the response of the resolve handler is returned as is
+			require.Equal(t, DIDLDJSON, rr.Header().Get("Content-Type"), accept)
+			require.Equal(t, expected.Body.String(), rr.Body.String(), accept)
+		}
+	})
+
+	t.Run("representations", func(t *testing.T) {
+		for accept, contentType := range map[string]string{
+			ResolutionResult:        ResolutionResult,
+			"application/json":      JSON,
+			DIDLDJSON:               DIDLDJSON,
+			DIDJSON:                 DIDJSON,
+			DIDCBOR:                 DIDCBOR,
+			"text/html, " + DIDJSON: DIDJSON,
+		} {
+			rr := serveHTTP(handler, accept)
+			require.Equal(t, http.StatusOK, rr.Code, accept)
+			require.Equal(t, contentType, rr.Header().Get("Content-Type"), accept)
+
+			result := &document.ResolutionResult{}
+			require.NoError(t, json.Unmarshal(serveHTTP(resolveHandler, "").Body.Bytes(), result))
+
+			expected, err := Encode(contentType, result)
+			require.NoError(t, err)
+			require.Equal(t, expected, rr.Body.Bytes(), accept)
+		}
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		rr := serveHTTP(handler, "text/html")
+		require.Equal(t, http.StatusNotAcceptable, rr.Code)
+		require.Contains(t, rr.Body.String(), "none of the representations in [text/html] is supported")
+	})
+
+	t.Run("resolve error", func(t *testing.T) {
+		rr := serveHTTP(NewHandler(func(rw http.ResponseWriter, req *http.Request) {
+			common.WriteError(rw, http.StatusNotFound, errors.New("document not found"))
+		}), DIDJSON)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Equal(t, "text/plain", rr.Header().Get("Content-Type"))
+		require.Equal(t, "document not found", rr.Body.String())
+	})
+
+	t.Run("invalid resolution result", func(t *testing.T) {
+		rr := serveHTTP(NewHandler(func(rw http.ResponseWriter, req *http.Request) {
+			_, err := rw.Write([]byte("invalid"))
+			require.NoError(t, err)
+		}), DIDJSON)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "unmarshal resolution result")
+	})
+}
+
+func serveHTTP(handler common.HTTPRequestHandler, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/sidetree/v1/identifiers/"+did, nil)
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler(rr, req)
+
+	return rr
+}
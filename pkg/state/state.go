@@ -0,0 +1,452 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package state
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-core-go/pkg/hashing"
+)
+
+var logger = logrus.New()
+
+// sha2_256 is the multihash algorithm that the CAS uses to calculate the address of content
+const sha2_256 = 18
+
+// Archive entry names
+const (
+	cursorEntry     = "cursor.json"
+	ledgerEntry     = "ledger.json"
+	operationsEntry = "operations.json"
+	casPrefix       = "cas/"
+)
+
+// OperationStore holds the anchored operations of a namespace
+type OperationStore interface {
+	Operations() []*operation.AnchoredOperation
+	Restore(ops []*operation.AnchoredOperation) error
+}
+
+// CAS holds the batch files of the node
+type CAS interface {
+	Addresses() ([]string, error)
+	Read(address string) ([]byte, error)
+	Write(content []byte) (string, error)
+	Reset() error
+}
+
+// Ledger holds the anchored transactions of the node
+type Ledger interface {
+	Transactions() []*txn.SidetreeTxn
+	Restore(txns []*txn.SidetreeTxn) error
+}
+
+// Cursor holds the number of the last transaction processed by the observer
+type Cursor interface {
+	Get() int
+	Set(value int) error
+}
+
+// OperationQueue holds the operations of a namespace that are waiting to be batched
+type OperationQueue interface {
+	Remove(num uint) (ops operation.QueuedOperationsAtTime, ack func() uint, nack func(), err error)
+	Len() uint
+}
+
+// Observer is paused while state is being restored or reset
+type Observer interface {
+	Pause()
+	Resume()
+}
+
+// BatchWriter is paused while state is being restored or reset so that no operations are accepted and no batches
+// are anchored. Pause must wait until the batch that is being anchored (if any) has been anchored.
+type BatchWriter interface {
+	Pause()
+	Resume()
+}
+
+// Providers contains the sources of the node state
+type Providers struct {
+	// OperationStores holds the operation store of each namespace
+	OperationStores map[string]OperationStore
+	CAS             CAS
+	Ledger          Ledger
+	Cursor          Cursor
+	OperationQueues []OperationQueue
+	BatchWriters    []BatchWriter
+	Observer        Observer
+}
+
+// Manager exports the node state to an archive, restores the node state from an archive and resets the node state
+type Manager struct {
+	*Providers
+}
+
+// New returns a new state manager
+func New(providers *Providers) *Manager {
+	return &Manager{Providers: providers}
+}
+
+// snapshot holds the complete state of the node
+type snapshot struct {
+	cursor     int
+	txns       []*txn.SidetreeTxn
+	operations []*operation.AnchoredOperation
+	cas        map[string][]byte
+}
+
+type cursorModel struct {
+	TransactionNumber int `json:"transactionNumber"`
+}
+
+// Export writes the node state to the given writer as a gzipped tar archive
+func (m *Manager) Export(w io.Writer) error {
+	// The cursor is captured before the ledger and the ledger before CAS and the operation store so that
+	// the archive never references transactions, batch files or operations that it doesn't contain.
+	// Operations of transactions after the cursor may be included - they are ignored when the observer
+	// re-processes those transactions.
+	cursor := m.Cursor.Get()
+	txns := m.Ledger.Transactions()
+
+	addresses, err := m.CAS.Addresses()
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := writeJSONEntry(tw, cursorEntry, &cursorModel{TransactionNumber: cursor}); err != nil {
+		return err
+	}
+
+	if err := writeJSONEntry(tw, ledgerEntry, txns); err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		content, err := m.CAS.Read(address)
+		if err != nil {
+			return fmt.Errorf("read CAS content [%s]: %w", address, err)
+		}
+
+		if err := writeEntry(tw, casPrefix+address, content); err != nil {
+			return err
+		}
+	}
+
+	ops := m.operations()
+	if err := writeJSONEntry(tw, operationsEntry, ops); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+
+	logger.Infof("exported state: cursor[%d], transactions[%d], CAS entries[%d], operations[%d]",
+		cursor, len(txns), len(addresses), len(ops))
+
+	return nil
+}
+
+// Import replaces the node state with the state in the given archive and discards the queued operations. The
+// archive is validated before any state is changed.
+func (m *Manager) Import(r io.Reader) error {
+	s, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+
+	// the whole archive is validated before any state is changed
+	opsByNamespace, err := m.groupByNamespace(s.operations, s.txns)
+	if err != nil {
+		return err
+	}
+
+	for address, content := range s.cas {
+		if err := validateAddress(address, content); err != nil {
+			return err
+		}
+	}
+
+	defer m.pause()()
+
+	if err := m.clearOperationQueues(); err != nil {
+		return err
+	}
+
+	if err := m.CAS.Reset(); err != nil {
+		return fmt.Errorf("reset CAS: %w", err)
+	}
+
+	for address, content := range s.cas {
+		if _, err := m.CAS.Write(content); err != nil {
+			return fmt.Errorf("write CAS content [%s]: %w", address, err)
+		}
+	}
+
+	if err := m.Ledger.Restore(s.txns); err != nil {
+		return fmt.Errorf("restore ledger: %w", err)
+	}
+
+	for namespace, opStore := range m.OperationStores {
+		if err := opStore.Restore(opsByNamespace[namespace]); err != nil {
+			return fmt.Errorf("restore operation store of namespace [%s]: %w", namespace, err)
+		}
+	}
+
+	if err := m.Cursor.Set(s.cursor); err != nil {
+		return fmt.Errorf("restore cursor: %w", err)
+	}
+
+	logger.Infof("imported state: cursor[%d], transactions[%d], CAS entries[%d], operations[%d]",
+		s.cursor, len(s.txns), len(s.cas), len(s.operations))
+
+	return nil
+}
+
+// Reset removes all state from the node: the operation store, CAS, operation queue and ledger are cleared
+// and the observer cursor is moved back before the first transaction
+func (m *Manager) Reset() error {
+	defer m.pause()()
+
+	if err := m.clearOperationQueues(); err != nil {
+		return err
+	}
+
+	if err := m.CAS.Reset(); err != nil {
+		return fmt.Errorf("reset CAS: %w", err)
+	}
+
+	if err := m.Ledger.Restore(nil); err != nil {
+		return fmt.Errorf("reset ledger: %w", err)
+	}
+
+	for namespace, opStore := range m.OperationStores {
+		if err := opStore.Restore(nil); err != nil {
+			return fmt.Errorf("reset operation store of namespace [%s]: %w", namespace, err)
+		}
+	}
+
+	if err := m.Cursor.Set(-1); err != nil {
+		return fmt.Errorf("reset cursor: %w", err)
+	}
+
+	logger.Infof("node state has been reset")
+
+	return nil
+}
+
+// pause pauses the batch writers and the observer and returns a function that resumes them. The batch writers are
+// paused first since the observer may process a batch while it is being anchored.
+func (m *Manager) pause() func() {
+	for _, w := range m.BatchWriters {
+		w.Pause()
+	}
+
+	if m.Observer != nil {
+		m.Observer.Pause()
+	}
+
+	return func() {
+		if m.Observer != nil {
+			m.Observer.Resume()
+		}
+
+		for _, w := range m.BatchWriters {
+			w.Resume()
+		}
+	}
+}
+
+// clearOperationQueues removes all operations from the operation queues
+func (m *Manager) clearOperationQueues() error {
+	for _, opQueue := range m.OperationQueues {
+		_, ack, _, err := opQueue.Remove(opQueue.Len())
+		if err != nil {
+			return fmt.Errorf("reset operation queue: %w", err)
+		}
+
+		ack()
+	}
+
+	return nil
+}
+
+// operations returns the operations of all namespaces
+func (m *Manager) operations() []*operation.AnchoredOperation {
+	namespaces := make([]string, 0, len(m.OperationStores))
+	for namespace := range m.OperationStores {
+		namespaces = append(namespaces, namespace)
+	}
+
+	sort.Strings(namespaces)
+
+	var ops []*operation.AnchoredOperation
+	for _, namespace := range namespaces {
+		ops = append(ops, m.OperationStores[namespace].Operations()...)
+	}
+
+	return ops
+}
+
+// groupByNamespace groups the given operations by the namespace of the transaction that anchored them. Operations
+// of transactions that aren't in the ledger are dropped since the observer processes those transactions again.
+func (m *Manager) groupByNamespace(ops []*operation.AnchoredOperation,
+	txns []*txn.SidetreeTxn) (map[string][]*operation.AnchoredOperation, error) {
+	namespaces := make(map[uint64]string, len(txns))
+	for _, t := range txns {
+		namespaces[t.TransactionNumber] = t.Namespace
+	}
+
+	opsByNamespace := make(map[string][]*operation.AnchoredOperation)
+
+	for _, op := range ops {
+		namespace, ok := namespaces[op.TransactionNumber]
+		if !ok {
+			logger.Warnf("ignoring operation of transaction %d which is not in the ledger", op.TransactionNumber)
+
+			continue
+		}
+
+		if _, ok := m.OperationStores[namespace]; !ok {
+			return nil, fmt.Errorf("archive contains operations of namespace [%s] which is not hosted by this node",
+				namespace)
+		}
+
+		opsByNamespace[namespace] = append(opsByNamespace[namespace], op)
+	}
+
+	return opsByNamespace, nil
+}
+
+// validateAddress returns an error if the address of the CAS content isn't the multihash of the content. Content is
+// written to the CAS under its sha2-256 address so other multihash algorithms are rejected.
+func validateAddress(address string, content []byte) error {
+	mh, err := hashing.GetMultihash(address)
+	if err != nil {
+		return fmt.Errorf("invalid CAS address [%s]: %w", address, err)
+	}
+
+	if mh.Code != sha2_256 {
+		return fmt.Errorf("CAS address [%s] uses multihash algorithm [%d] instead of sha2-256", address, mh.Code)
+	}
+
+	hash, err := hashing.ComputeMultihash(sha2_256, content)
+	if err != nil {
+		return fmt.Errorf("invalid CAS address [%s]: %w", address, err)
+	}
+
+	if encoder.EncodeToString(hash) != address {
+		return fmt.Errorf("CAS content [%s] does not match its address", address)
+	}
+
+	return nil
+}
+
+func readSnapshot(r io.Reader) (*snapshot, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+
+	tr := tar.NewReader(gr)
+
+	s := &snapshot{cas: make(map[string][]byte)}
+	found := make(map[string]bool)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read archive entry [%s]: %w", hdr.Name, err)
+		}
+
+		found[hdr.Name] = true
+
+		switch {
+		case hdr.Name == cursorEntry:
+			c := &cursorModel{}
+			err = json.Unmarshal(content, c)
+			s.cursor = c.TransactionNumber
+		case hdr.Name == ledgerEntry:
+			err = json.Unmarshal(content, &s.txns)
+		case hdr.Name == operationsEntry:
+			err = json.Unmarshal(content, &s.operations)
+		case strings.HasPrefix(hdr.Name, casPrefix):
+			s.cas[strings.TrimPrefix(hdr.Name, casPrefix)] = content
+		default:
+			logger.Warnf("ignoring unknown archive entry [%s]", hdr.Name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("parse archive entry [%s]: %w", hdr.Name, err)
+		}
+	}
+
+	for _, name := range []string{cursorEntry, ledgerEntry, operationsEntry} {
+		if !found[name] {
+			return nil, fmt.Errorf("archive entry [%s] not found", name)
+		}
+	}
+
+	return s, nil
+}
+
+func writeJSONEntry(tw *tar.Writer, name string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal archive entry [%s]: %w", name, err)
+	}
+
+	return writeEntry(tw, name, content)
+}
+
+func writeEntry(tw *tar.Writer, name string, content []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write archive entry [%s]: %w", name, err)
+	}
+
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("write archive entry [%s]: %w", name, err)
+	}
+
+	return nil
+}
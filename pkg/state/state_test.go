@@ -0,0 +1,297 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package state
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/batch/opqueue"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-core-go/pkg/hashing"
+	coremocks "github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/sidetree-mock/pkg/batchwriter"
+	sidetreecontext "github.com/trustbloc/sidetree-mock/pkg/context"
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+	"github.com/trustbloc/sidetree-mock/pkg/observer"
+	sidetreeopqueue "github.com/trustbloc/sidetree-mock/pkg/opqueue"
+)
+
+const testNS = "did:test"
+
+const sha2_512 = 19
+
+func TestExportImport(t *testing.T) {
+	source := newProviders()
+
+	address, err := source.CAS.Write([]byte("core index file"))
+	require.NoError(t, err)
+	require.NoError(t, source.Ledger.(*observer.AnchorWriter).WriteAnchor("1."+address, nil, nil, 0))
+	require.NoError(t, source.Ledger.(*observer.AnchorWriter).ForNamespace(testNS).WriteAnchor("1."+address, nil, nil, 0))
+	require.NoError(t, opStore(source, mocks.DefaultNS).Put([]*operation.AnchoredOperation{
+		{UniqueSuffix: "suffix", Type: operation.TypeCreate, OperationRequest: []byte("request"), TransactionNumber: 0},
+	}))
+	require.NoError(t, opStore(source, testNS).Put([]*operation.AnchoredOperation{
+		{UniqueSuffix: "suffix2", Type: operation.TypeCreate, OperationRequest: []byte("request2"), TransactionNumber: 1},
+		{UniqueSuffix: "suffix3", Type: operation.TypeCreate, TransactionNumber: 2},
+	}))
+	require.NoError(t, source.Cursor.Set(0))
+
+	archive := &bytes.Buffer{}
+	require.NoError(t, New(source).Export(archive))
+
+	target := newProviders()
+	_, err = target.CAS.Write([]byte("stale content"))
+	require.NoError(t, err)
+	for _, q := range target.OperationQueues {
+		_, err = q.(*opqueue.MemQueue).Add(&operation.QueuedOperation{UniqueSuffix: "suffix4"}, 0)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, New(target).Import(bytes.NewReader(archive.Bytes())))
+
+	for _, q := range target.OperationQueues {
+		require.Zero(t, q.Len())
+	}
+
+	addresses, err := target.CAS.Addresses()
+	require.NoError(t, err)
+	require.Equal(t, []string{address}, addresses)
+	require.Equal(t, 0, target.Cursor.Get())
+	require.Len(t, target.Ledger.Transactions(), 2)
+	require.Equal(t, "1."+address, target.Ledger.Transactions()[0].AnchorString)
+	require.Equal(t, testNS, target.Ledger.Transactions()[1].Namespace)
+
+	ops, err := opStore(target, mocks.DefaultNS).Get("suffix")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, []byte("request"), ops[0].OperationRequest)
+
+	// the operations of each namespace are restored to the operation store of the namespace
+	ops = opStore(target, testNS).Operations()
+	require.Len(t, ops, 1)
+	require.Equal(t, []byte("request2"), ops[0].OperationRequest)
+
+	t.Run("namespace not hosted", func(t *testing.T) {
+		p := newProviders()
+		delete(p.OperationStores, testNS)
+
+		err := New(p).Import(bytes.NewReader(archive.Bytes()))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "archive contains operations of namespace [did:test] which is not hosted by this node")
+	})
+
+	t.Run("invalid archive", func(t *testing.T) {
+		err := New(newProviders()).Import(bytes.NewReader([]byte("invalid")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open archive")
+	})
+
+	t.Run("missing entry", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, writeJSONEntry(tw, cursorEntry, &cursorModel{}))
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		err := New(newProviders()).Import(buf)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "archive entry [ledger.json] not found")
+	})
+
+	t.Run("CAS content doesn't match address", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, writeJSONEntry(tw, cursorEntry, &cursorModel{}))
+		require.NoError(t, writeJSONEntry(tw, ledgerEntry, []string{}))
+		require.NoError(t, writeJSONEntry(tw, operationsEntry, []string{}))
+		require.NoError(t, writeEntry(tw, casPrefix+address, []byte("other content")))
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		p := newProviders()
+		stale, err := p.CAS.Write([]byte("stale content"))
+		require.NoError(t, err)
+
+		err = New(p).Import(buf)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not match its address")
+
+		// the state is left unchanged
+		addresses, err := p.CAS.Addresses()
+		require.NoError(t, err)
+		require.Equal(t, []string{stale}, addresses)
+	})
+
+	t.Run("CAS address of other multihash algorithm", func(t *testing.T) {
+		hash, err := hashing.ComputeMultihash(sha2_512, []byte("content"))
+		require.NoError(t, err)
+
+		address := encoder.EncodeToString(hash)
+
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, writeJSONEntry(tw, cursorEntry, &cursorModel{}))
+		require.NoError(t, writeJSONEntry(tw, ledgerEntry, []string{}))
+		require.NoError(t, writeJSONEntry(tw, operationsEntry, []string{}))
+		require.NoError(t, writeEntry(tw, casPrefix+address, []byte("content")))
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		err = New(newProviders()).Import(buf)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "CAS address ["+address+"] uses multihash algorithm [19] instead of sha2-256")
+	})
+
+	t.Run("invalid CAS address", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, writeJSONEntry(tw, cursorEntry, &cursorModel{}))
+		require.NoError(t, writeJSONEntry(tw, ledgerEntry, []string{}))
+		require.NoError(t, writeJSONEntry(tw, operationsEntry, []string{}))
+		require.NoError(t, writeEntry(tw, casPrefix+"address", []byte("content")))
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		err := New(newProviders()).Import(buf)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid CAS address [address]")
+	})
+}
+
+func TestReset(t *testing.T) {
+	p := newProviders()
+
+	_, err := p.CAS.Write([]byte("core index file"))
+	require.NoError(t, err)
+	require.NoError(t, p.Ledger.(*observer.AnchorWriter).WriteAnchor("1.address", nil, nil, 0))
+	require.NoError(t, opStore(p, mocks.DefaultNS).Put([]*operation.AnchoredOperation{
+		{UniqueSuffix: "suffix", Type: operation.TypeCreate},
+	}))
+	require.NoError(t, opStore(p, testNS).Put([]*operation.AnchoredOperation{
+		{UniqueSuffix: "suffix2", Type: operation.TypeCreate},
+	}))
+	require.NoError(t, p.Cursor.Set(0))
+	for _, q := range p.OperationQueues {
+		_, err = q.(*opqueue.MemQueue).Add(&operation.QueuedOperation{UniqueSuffix: "suffix2"}, 0)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, New(p).Reset())
+
+	addresses, err := p.CAS.Addresses()
+	require.NoError(t, err)
+	require.Empty(t, addresses)
+	require.Empty(t, p.Ledger.Transactions())
+	require.Empty(t, opStore(p, mocks.DefaultNS).Operations())
+	require.Empty(t, opStore(p, testNS).Operations())
+	require.Equal(t, -1, p.Cursor.Get())
+
+	for _, q := range p.OperationQueues {
+		require.Zero(t, q.Len())
+	}
+}
+
+func TestResetWhileAnchoring(t *testing.T) {
+	p := newProviders()
+	anchorWriter := p.Ledger.(*observer.AnchorWriter)
+
+	var once sync.Once
+
+	anchoring := make(chan struct{})
+	release := make(chan struct{})
+
+	// the first batch is held back while it is being anchored
+	handler := &coremocks.OperationHandler{}
+	handler.PrepareTxnFilesStub = func(ops []*operation.QueuedOperation) (*protocol.AnchoringInfo, error) {
+		once.Do(func() { close(anchoring) })
+		<-release
+
+		return &protocol.AnchoringInfo{AnchorString: "1." + ops[0].UniqueSuffix}, nil
+	}
+
+	pv := &coremocks.ProtocolVersion{}
+	pv.ProtocolReturns(mocks.DefaultProtocol())
+	pv.OperationHandlerReturns(handler)
+
+	pc := coremocks.NewMockProtocolClient()
+	pc.CurrentVersion = pv
+	pc.Versions = []*coremocks.ProtocolVersion{pv}
+
+	queue := sidetreeopqueue.New()
+
+	w, err := batchwriter.New(mocks.DefaultNS, sidetreecontext.New(pc, anchorWriter, queue))
+	require.NoError(t, err)
+
+	w.Start()
+	defer w.Stop()
+
+	p.OperationQueues = []OperationQueue{queue}
+	p.BatchWriters = []BatchWriter{w}
+
+	require.NoError(t, w.Add(&operation.QueuedOperation{UniqueSuffix: "suffix1"}, 0))
+
+	<-anchoring
+
+	reset := make(chan error)
+
+	go func() {
+		reset <- New(p).Reset()
+	}()
+
+	// the reset waits until the batch has been anchored
+	select {
+	case err := <-reset:
+		require.Failf(t, "reset didn't wait for the batch", "error: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// operations that are submitted during the reset are only added after the reset
+	added := make(chan error)
+
+	go func() {
+		added <- w.Add(&operation.QueuedOperation{UniqueSuffix: "suffix2"}, 0)
+	}()
+
+	close(release)
+
+	require.NoError(t, <-reset)
+	require.NoError(t, <-added)
+
+	require.Eventually(t, func() bool { return len(anchorWriter.Transactions()) == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, "1.suffix2", anchorWriter.Transactions()[0].AnchorString)
+	require.Equal(t, -1, p.Cursor.Get())
+}
+
+func newProviders() *Providers {
+	return &Providers{
+		OperationStores: map[string]OperationStore{
+			mocks.DefaultNS: mocks.NewMockOperationStore(),
+			testNS:          mocks.NewMockOperationStore(),
+		},
+		CAS:             mocks.NewMockCasClient(nil),
+		Ledger:          observer.NewAnchorWriter(mocks.DefaultNS),
+		Cursor:          observer.NewCursor(),
+		OperationQueues: []OperationQueue{&opqueue.MemQueue{}, &opqueue.MemQueue{}},
+	}
+}
+
+func opStore(p *Providers, namespace string) *mocks.MockOperationStore {
+	return p.OperationStores[namespace].(*mocks.MockOperationStore)
+}
@@ -0,0 +1,331 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+
+	"github.com/trustbloc/sidetree-mock/pkg/representation"
+)
+
+// DereferencingResultContentType is the content type of a DID URL dereferencing result.
+const DereferencingResultContentType = `application/ld+json;profile="https://w3id.org/did-url-dereferencing"`
+
+// DID URL parameters.
+const (
+	serviceParam     = "service"
+	relativeRefParam = "relativeRef"
+	versionIDParam   = "versionId"
+	versionTimeParam = "versionTime"
+)
+
+// verificationRelationships are the properties of a DID document that may hold embedded verification methods
+var verificationRelationships = []string{
+	document.AuthenticationProperty,
+	document.AssertionMethodProperty,
+	document.KeyAgreementProperty,
+	document.InvocationKeyProperty,
+	document.DelegationKeyProperty,
+}
+
+// didURL is a DID URL split into the DID, the query parameters and the fragment
+type didURL struct {
+	did      string
+	query    url.Values
+	fragment string
+}
+
+// parseDIDURL parses the given DID URL. The query of the DID URL may be part of the given DID URL (if it was
+// percent-encoded in the request path) or it may be given separately (the query of the request).
+func parseDIDURL(id, rawQuery string) (*didURL, error) {
+	if !strings.HasPrefix(id, "did:") {
+		return nil, fmt.Errorf("invalid DID [%s]", id)
+	}
+
+	u := &didURL{}
+
+	if i := strings.Index(id, "#"); i >= 0 {
+		u.fragment = id[i+1:]
+		id = id[:i]
+	}
+
+	if i := strings.Index(id, "?"); i >= 0 {
+		if rawQuery != "" {
+			rawQuery = id[i+1:] + "&" + rawQuery
+		} else {
+			rawQuery = id[i+1:]
+		}
+
+		id = id[:i]
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query in DID URL [%s]: %w", id, err)
+	}
+
+	u.did = id
+	u.query = query
+
+	return u, nil
+}
+
+// isDID returns true if the DID URL identifies the DID document (possibly at a given version) rather than a
+// resource within or referenced by the DID document
+func (u *didURL) isDID() bool {
+	return u.fragment == "" && u.query.Get(serviceParam) == "" && u.query.Get(relativeRefParam) == ""
+}
+
+func (u *didURL) resolutionOptions() ([]document.ResolutionOption, error) {
+	versionID := u.query.Get(versionIDParam)
+	versionTime := u.query.Get(versionTimeParam)
+
+	switch {
+	case versionID != "" && versionTime != "":
+		return nil, fmt.Errorf("cannot specify both '%s' and '%s'", versionIDParam, versionTimeParam)
+	case versionID != "":
+		return []document.ResolutionOption{document.WithVersionID(versionID)}, nil
+	case versionTime != "":
+		return []document.ResolutionOption{document.WithVersionTime(versionTime)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// dereference dereferences a DID URL as specified by the DID URL dereferencing algorithm of DID Core: the DID is
+// resolved and then the service selected by the service parameter or the object of the DID document identified by
+// the fragment is returned. If the selected service has a URL as service endpoint then the request is redirected
+// to the service endpoint (resolved against the relativeRef parameter), unless the dereferencing result was
+// requested.
+func (o *Operation) dereference(rw http.ResponseWriter, r *http.Request, didURL *didURL) {
+	contentType, ok := representation.Negotiate(r.Header.Get("Accept"), DIDLDJSONContentType,
+		DereferencingResultContentType)
+	if !ok {
+		writeDereferencingError(rw, http.StatusNotAcceptable, ErrRepresentationNotSupported,
+			fmt.Sprintf("representation [%s] is not supported", r.Header.Get("Accept")))
+
+		return
+	}
+
+	result, status, errCode, err := o.resolveDID(didURL)
+	if err != nil {
+		writeDereferencingError(rw, status, errCode, err.Error())
+
+		return
+	}
+
+	if status == http.StatusGone {
+		writeResponse(rw, status, DereferencingResultContentType, &DereferencingResult{
+			Context:               didResolutionContext,
+			DereferencingMetadata: &DereferencingMetadata{},
+			ContentMetadata:       documentMetadata(result),
+		})
+
+		return
+	}
+
+	serviceID := didURL.query.Get(serviceParam)
+	relativeRef := didURL.query.Get(relativeRefParam)
+
+	if serviceID == "" {
+		if relativeRef != "" {
+			writeDereferencingError(rw, http.StatusBadRequest, ErrInvalidDIDURL,
+				fmt.Sprintf("'%s' requires '%s'", relativeRefParam, serviceParam))
+
+			return
+		}
+
+		dereferenceFragment(rw, contentType, didURL, result)
+
+		return
+	}
+
+	service, ok := selectObject(objects(result.Document, document.ServiceProperty), serviceID)
+	if !ok {
+		writeDereferencingError(rw, http.StatusNotFound, ErrNotFound,
+			fmt.Sprintf("service [%s] not found in DID document [%s]", serviceID, didURL.did))
+
+		return
+	}
+
+	endpoint, ok := service[document.ServiceEndpointProperty].(string)
+	if !ok {
+		if relativeRef != "" {
+			writeDereferencingError(rw, http.StatusBadRequest, ErrInvalidDIDURL,
+				fmt.Sprintf("endpoint of service [%s] is not a URL", serviceID))
+
+			return
+		}
+
+		// a service endpoint that isn't a URL (e.g. a map) can't be redirected to so the service is returned
+		writeContent(rw, contentType, result, service)
+
+		return
+	}
+
+	target, err := serviceURL(endpoint, relativeRef, didURL.fragment)
+	if err != nil {
+		writeDereferencingError(rw, http.StatusBadRequest, ErrInvalidDIDURL, err.Error())
+
+		return
+	}
+
+	if contentType == DereferencingResultContentType {
+		writeContent(rw, contentType, result, target)
+
+		return
+	}
+
+	http.Redirect(rw, r, target, http.StatusSeeOther)
+}
+
+// dereferenceFragment returns the verification method or service of the DID document that is identified by the
+// fragment of the DID URL
+func dereferenceFragment(rw http.ResponseWriter, contentType string, didURL *didURL,
+	result *document.ResolutionResult) {
+	doc := result.Document
+
+	candidates := append(objects(doc, document.VerificationMethodProperty), objects(doc, document.ServiceProperty)...)
+
+	for _, property := range verificationRelationships {
+		candidates = append(candidates, objects(doc, property)...)
+	}
+
+	obj, ok := selectObject(candidates, didURL.fragment)
+	if !ok {
+		writeDereferencingError(rw, http.StatusNotFound, ErrNotFound,
+			fmt.Sprintf("fragment [%s] not found in DID document [%s]", didURL.fragment, didURL.did))
+
+		return
+	}
+
+	// the object is returned in the context of the DID document
+	content := make(map[string]interface{}, len(obj)+1)
+	for k, v := range obj {
+		content[k] = v
+	}
+
+	if ctx, ok := doc[document.ContextProperty]; ok {
+		content[document.ContextProperty] = ctx
+	}
+
+	writeContent(rw, contentType, result, content)
+}
+
+// selectObject returns the object with the given ID (the fragment of the object ID, which may be relative or
+// absolute)
+func selectObject(candidates []map[string]interface{}, id string) (map[string]interface{}, bool) {
+	for _, obj := range candidates {
+		objectID, ok := obj[document.IDProperty].(string)
+		if !ok {
+			continue
+		}
+
+		if i := strings.LastIndex(objectID, "#"); i >= 0 {
+			objectID = objectID[i+1:]
+		}
+
+		if objectID == id {
+			return obj, true
+		}
+	}
+
+	return nil, false
+}
+
+// serviceURL resolves the relative reference against the service endpoint (RFC 3986 section 5.2) and appends the
+// fragment of the DID URL
+func serviceURL(endpoint, relativeRef, fragment string) (string, error) {
+	base, err := url.Parse(endpoint)
+	if err != nil || !base.IsAbs() {
+		return "", fmt.Errorf("service endpoint [%s] is not a URL", endpoint)
+	}
+
+	target := base
+
+	if relativeRef != "" {
+		ref, err := url.Parse(relativeRef)
+		if err != nil || ref.IsAbs() {
+			return "", fmt.Errorf("invalid relative reference [%s]", relativeRef)
+		}
+
+		target = base.ResolveReference(ref)
+	}
+
+	if fragment != "" {
+		target.Fragment = fragment
+	}
+
+	return target.String(), nil
+}
+
+// writeContent writes the dereferenced content or, if requested, the dereferencing result
+func writeContent(rw http.ResponseWriter, contentType string, result *document.ResolutionResult, content interface{}) {
+	if contentType != DereferencingResultContentType {
+		writeResponse(rw, http.StatusOK, contentType, content)
+
+		return
+	}
+
+	writeResponse(rw, http.StatusOK, contentType, &DereferencingResult{
+		Context:               didResolutionContext,
+		DereferencingMetadata: &DereferencingMetadata{ContentType: DIDLDJSONContentType},
+		ContentStream:         content,
+		ContentMetadata:       documentMetadata(result),
+	})
+}
+
+// writeDereferencingError writes a dereferencing result with the given error in the dereferencing metadata.
+func writeDereferencingError(rw http.ResponseWriter, status int, code, msg string) {
+	writeResponse(rw, status, DereferencingResultContentType, &DereferencingResult{
+		Context:               didResolutionContext,
+		DereferencingMetadata: &DereferencingMetadata{Error: code, ErrorMessage: msg},
+		ContentMetadata:       make(document.Metadata),
+	})
+}
+
+// objects returns the objects in the given list property of the DID document (references to objects are skipped).
+// The DID document may have been built by the document transformer (typed lists) or decoded from JSON.
+func objects(doc document.Document, property string) []map[string]interface{} {
+	var objs []map[string]interface{}
+
+	switch entries := doc[property].(type) {
+	case []document.Service:
+		for _, entry := range entries {
+			objs = append(objs, entry)
+		}
+	case []document.PublicKey:
+		for _, entry := range entries {
+			objs = append(objs, entry)
+		}
+	case []interface{}:
+		for _, entry := range entries {
+			if obj, ok := object(entry); ok {
+				objs = append(objs, obj)
+			}
+		}
+	}
+
+	return objs
+}
+
+func object(entry interface{}) (map[string]interface{}, bool) {
+	switch obj := entry.(type) {
+	case map[string]interface{}:
+		return obj, true
+	case document.Service:
+		return obj, true
+	case document.PublicKey:
+		return obj, true
+	default:
+		return nil, false
+	}
+}
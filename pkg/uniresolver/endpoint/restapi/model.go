@@ -0,0 +1,42 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+)
+
+// ResolutionResult is the DID resolution result.
+type ResolutionResult struct {
+	Context            string              `json:"@context"`
+	Document           document.Document   `json:"didDocument"`
+	ResolutionMetadata *ResolutionMetadata `json:"didResolutionMetadata"`
+	DocumentMetadata   document.Metadata   `json:"didDocumentMetadata"`
+}
+
+// ResolutionMetadata is the DID resolution metadata. Error is set if the DID couldn't be resolved.
+type ResolutionMetadata struct {
+	ContentType  string `json:"contentType,omitempty"`
+	Error        string `json:"error,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// DereferencingResult is the DID URL dereferencing result.
+type DereferencingResult struct {
+	Context               string                 `json:"@context"`
+	DereferencingMetadata *DereferencingMetadata `json:"dereferencingMetadata"`
+	ContentStream         interface{}            `json:"contentStream"`
+	ContentMetadata       document.Metadata      `json:"contentMetadata"`
+}
+
+// DereferencingMetadata is the DID URL dereferencing metadata. Error is set if the DID URL couldn't be
+// dereferenced.
+type DereferencingMetadata struct {
+	ContentType  string `json:"contentType,omitempty"`
+	Error        string `json:"error,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
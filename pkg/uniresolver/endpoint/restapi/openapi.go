@@ -0,0 +1,46 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+// resolveReq model
+//
+// swagger:parameters resolveReq
+type resolveReq struct { // nolint: unused,deadcode
+	// in: path
+	// required: true
+	DID string `json:"did"`
+
+	// in: query
+	Service string `json:"service"`
+
+	// in: query
+	RelativeRef string `json:"relativeRef"`
+
+	// in: query
+	VersionID string `json:"versionId"`
+
+	// in: query
+	VersionTime string `json:"versionTime"`
+
+	// in: header
+	Accept string `json:"Accept"`
+}
+
+// resolveResp model
+//
+// swagger:response resolveResp
+type resolveResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *ResolutionResult
+}
+
+// dereferenceResp model
+//
+// swagger:response dereferenceResp
+type dereferenceResp struct { // nolint: unused,deadcode
+	// in: body
+	Body *DereferencingResult
+}
@@ -0,0 +1,250 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/representation"
+)
+
+var logger = log.New("uniresolver-rest")
+
+// API endpoints.
+const (
+	identifiersEndpoint = "/1.0/identifiers/{did}"
+)
+
+const didParam = "did"
+
+const didResolutionContext = "https://w3id.org/did-resolution/v1"
+
+// Content types of the representations returned by the driver.
+const (
+	// ResolutionResultContentType is the content type of a DID resolution result.
+	ResolutionResultContentType = representation.ResolutionResult
+	// DIDLDJSONContentType is the content type of a JSON-LD DID document.
+	DIDLDJSONContentType = representation.DIDLDJSON
+)
+
+// Errors reported in the DID resolution metadata.
+const (
+	ErrInvalidDID                 = "invalidDid"
+	ErrInvalidDIDURL              = "invalidDidUrl"
+	ErrNotFound                   = "notFound"
+	ErrMethodNotSupported         = "methodNotSupported"
+	ErrRepresentationNotSupported = "representationNotSupported"
+	ErrInternal                   = "internalError"
+)
+
+// Resolver resolves DID documents.
+type Resolver interface {
+	ResolveDocument(id string, opts ...document.ResolutionOption) (*document.ResolutionResult, error)
+}
+
+// New returns Universal Resolver driver operations.
+func New(c *Config) *Operation {
+	return &Operation{
+		resolvers: c.Resolvers,
+	}
+}
+
+// Operation defines handlers for the Universal Resolver driver interface.
+type Operation struct {
+	resolvers map[string]Resolver
+}
+
+// Config defines configuration for Universal Resolver driver operations.
+type Config struct {
+	// Resolvers holds the resolver of each DID namespace (and namespace alias) hosted by the node.
+	Resolvers map[string]Resolver
+}
+
+// GetRESTHandlers get all controller API handler available for this service.
+func (o *Operation) GetRESTHandlers() []common.HTTPHandler {
+	return []common.HTTPHandler{
+		newHTTPHandler(identifiersEndpoint, http.MethodGet, o.resolveHandler),
+	}
+}
+
+// resolveHandler swagger:route Get /1.0/identifiers/{did} uniresolver resolveReq
+//
+// resolveHandler resolves a DID or dereferences a DID URL as specified by the DIF Universal Resolver driver
+// interface. The DID resolution result is returned unless the DID document is requested with the
+// application/did+ld+json Accept header. DID URLs with a fragment or a service parameter are dereferenced.
+//
+// Responses:
+//    default: resolveResp
+//        200: resolveResp
+//        303: dereferenceResp
+func (o *Operation) resolveHandler(rw http.ResponseWriter, r *http.Request) {
+	didURL, err := parseDIDURL(mux.Vars(r)[didParam], r.URL.RawQuery)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, ErrInvalidDID, err.Error())
+
+		return
+	}
+
+	if didURL.isDID() {
+		o.resolve(rw, r, didURL)
+	} else {
+		o.dereference(rw, r, didURL)
+	}
+}
+
+func (o *Operation) resolve(rw http.ResponseWriter, r *http.Request, didURL *didURL) {
+	accept := r.Header.Get("Accept")
+
+	contentType, ok := representation.Negotiate(accept, ResolutionResultContentType, DIDLDJSONContentType)
+	if !ok {
+		writeError(rw, http.StatusNotAcceptable, ErrRepresentationNotSupported,
+			fmt.Sprintf("representation [%s] is not supported", accept))
+
+		return
+	}
+
+	// clients without preference (e.g. "*/*") get the resolution result like clients without Accept header
+	if !representation.Explicit(accept, ResolutionResultContentType, DIDLDJSONContentType) {
+		contentType = ResolutionResultContentType
+	}
+
+	result, status, errCode, err := o.resolveDID(didURL)
+	if err != nil {
+		writeError(rw, status, errCode, err.Error())
+
+		return
+	}
+
+	if contentType == DIDLDJSONContentType {
+		writeResponse(rw, status, contentType, result.Document)
+
+		return
+	}
+
+	writeResponse(rw, status, contentType, &ResolutionResult{
+		Context:            didResolutionContext,
+		Document:           result.Document,
+		ResolutionMetadata: &ResolutionMetadata{ContentType: DIDLDJSONContentType},
+		DocumentMetadata:   documentMetadata(result),
+	})
+}
+
+// resolveDID resolves the DID of the given DID URL (at the version given by the versionId or versionTime
+// parameter). The HTTP status is returned along with the result, which is 410 (Gone) for a deactivated DID. If
+// the DID can't be resolved then the HTTP status and the error code are returned along with the error.
+func (o *Operation) resolveDID(didURL *didURL) (*document.ResolutionResult, int, string, error) {
+	opts, err := didURL.resolutionOptions()
+	if err != nil {
+		return nil, http.StatusBadRequest, ErrInvalidDIDURL, err
+	}
+
+	resolver, ok := o.resolver(didURL.did)
+	if !ok {
+		return nil, http.StatusNotImplemented, ErrMethodNotSupported,
+			fmt.Errorf("DID [%s] is not of a namespace hosted by this node", didURL.did)
+	}
+
+	result, err := resolver.ResolveDocument(didURL.did, opts...)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "bad request"):
+			return nil, http.StatusBadRequest, ErrInvalidDID, err
+		case strings.Contains(err.Error(), "not found"):
+			return nil, http.StatusNotFound, ErrNotFound, fmt.Errorf("DID [%s] not found", didURL.did)
+		default:
+			logger.Errorf("Failed to resolve DID [%s]: %s", didURL.did, err)
+
+			return nil, http.StatusInternalServerError, ErrInternal, err
+		}
+	}
+
+	// the document of a deactivated DID is returned with status 410 (Gone)
+	if deactivated, ok := result.DocumentMetadata[document.DeactivatedProperty].(bool); ok && deactivated {
+		return result, http.StatusGone, "", nil
+	}
+
+	return result, http.StatusOK, "", nil
+}
+
+// resolver returns the resolver of the namespace of the given DID. If the DID matches several namespaces (e.g. a
+// namespace and an alias that extends it) then the resolver of the longest namespace is returned.
+func (o *Operation) resolver(did string) (Resolver, bool) {
+	var match string
+
+	for namespace := range o.resolvers {
+		if strings.HasPrefix(did, namespace+":") && len(namespace) > len(match) {
+			match = namespace
+		}
+	}
+
+	resolver, ok := o.resolvers[match]
+
+	return resolver, ok
+}
+
+func documentMetadata(result *document.ResolutionResult) document.Metadata {
+	if result.DocumentMetadata == nil {
+		return make(document.Metadata)
+	}
+
+	return result.DocumentMetadata
+}
+
+// writeError writes a resolution result with the given error in the resolution metadata.
+func writeError(rw http.ResponseWriter, status int, code, msg string) {
+	writeResponse(rw, status, ResolutionResultContentType, &ResolutionResult{
+		Context:            didResolutionContext,
+		ResolutionMetadata: &ResolutionMetadata{Error: code, ErrorMessage: msg},
+		DocumentMetadata:   make(document.Metadata),
+	})
+}
+
+// writeResponse writes response.
+func writeResponse(rw http.ResponseWriter, status int, contentType string, v interface{}) {
+	rw.Header().Set("Content-Type", contentType)
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(v)
+	if err != nil {
+		logger.Errorf("Unable to send a response: %s", err)
+	}
+}
+
+// newHTTPHandler returns instance of HTTPHandler which can be used to handle http requests.
+func newHTTPHandler(path, method string, handle common.HTTPRequestHandler) common.HTTPHandler {
+	return &httpHandler{path: path, method: method, handle: handle}
+}
+
+// HTTPHandler contains REST API handling details which can be used to build routers.
+// for http requests for given path.
+type httpHandler struct {
+	path   string
+	method string
+	handle common.HTTPRequestHandler
+}
+
+// Path returns http request path.
+func (h *httpHandler) Path() string {
+	return h.path
+}
+
+// Method returns http request method type.
+func (h *httpHandler) Method() string {
+	return h.method
+}
+
+// Handler returns http request handle func.
+func (h *httpHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
@@ -0,0 +1,462 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/uniresolver/endpoint/restapi"
+)
+
+const (
+	identifiersEndpoint = "/1.0/identifiers/{did}"
+
+	did = "did:sidetree:EiDahaOGH-liLLdDtTxEAdc8i-cfCz-WUcQdRJheMVNn3A"
+)
+
+func TestGetRESTHandlers(t *testing.T) {
+	c := restapi.New(&restapi.Config{})
+	require.Equal(t, 1, len(c.GetRESTHandlers()))
+}
+
+func TestResolve(t *testing.T) {
+	resolver := &mockResolver{
+		result: &document.ResolutionResult{
+			Context:          "https://w3id.org/did-resolution/v1",
+			Document:         document.Document{"@context": []interface{}{"https://www.w3.org/ns/did/v1"}, "id": did},
+			DocumentMetadata: document.Metadata{"canonicalId": did},
+		},
+	}
+
+	c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{"did:sidetree": resolver}})
+
+	handler := getHandler(t, c, identifiersEndpoint)
+
+	t.Run("resolution result", func(t *testing.T) {
+		for _, accept := range []string{
+			"",
+			restapi.ResolutionResultContentType,
+			`application/ld+json; profile="https://w3id.org/did-resolution"`,
+			"*/*",
+			"text/html, application/*;q=0.5",
+		} {
+			rr := serveHTTP(t, handler.Handler(), did, accept)
+			require.Equal(t, http.StatusOK, rr.Code, accept)
+			require.Equal(t, restapi.ResolutionResultContentType, rr.Header().Get("Content-Type"))
+
+			result := &restapi.ResolutionResult{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+			require.Equal(t, "https://w3id.org/did-resolution/v1", result.Context)
+			require.Equal(t, did, result.Document.ID())
+			require.Equal(t, did, result.DocumentMetadata["canonicalId"])
+			require.Equal(t, restapi.DIDLDJSONContentType, result.ResolutionMetadata.ContentType)
+			require.Empty(t, result.ResolutionMetadata.Error)
+		}
+	})
+
+	t.Run("DID document", func(t *testing.T) {
+		for _, accept := range []string{
+			restapi.DIDLDJSONContentType,
+			`application/did+ld+json;q=0.9, application/ld+json;profile="https://w3id.org/did-resolution";q=0.1`,
+		} {
+			rr := serveHTTP(t, handler.Handler(), did, accept)
+			require.Equal(t, http.StatusOK, rr.Code, accept)
+			require.Equal(t, restapi.DIDLDJSONContentType, rr.Header().Get("Content-Type"))
+
+			doc := document.Document{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+			require.Equal(t, did, doc.ID())
+			require.NotEmpty(t, doc.Context())
+		}
+	})
+
+	t.Run("deactivated", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{"did:sidetree": &mockResolver{
+			result: &document.ResolutionResult{
+				Document:         document.Document{"id": did},
+				DocumentMetadata: document.Metadata{"deactivated": true},
+			},
+		}}})
+
+		rr := serveHTTP(t, getHandler(t, c, identifiersEndpoint).Handler(), did, "")
+		require.Equal(t, http.StatusGone, rr.Code)
+
+		result := &restapi.ResolutionResult{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+		require.Equal(t, true, result.DocumentMetadata["deactivated"])
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		aliasResolver := &mockResolver{result: &document.ResolutionResult{Document: document.Document{"id": "alias"}}}
+
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{
+			"did:sidetree":            resolver,
+			"did:sidetree:domain.com": aliasResolver,
+		}})
+
+		rr := serveHTTP(t, getHandler(t, c, identifiersEndpoint).Handler(), "did:sidetree:domain.com:suffix",
+			restapi.DIDLDJSONContentType)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), "alias")
+	})
+
+	t.Run("representation not supported", func(t *testing.T) {
+		for _, accept := range []string{"text/html", "application/ld+json", "application/did+ld+json;q=0"} {
+			rr := serveHTTP(t, handler.Handler(), did, accept)
+			require.Equal(t, http.StatusNotAcceptable, rr.Code, accept)
+			requireError(t, rr, restapi.ErrRepresentationNotSupported)
+		}
+	})
+
+	t.Run("invalid DID", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), "sidetree:suffix", "")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		requireError(t, rr, restapi.ErrInvalidDID)
+
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{
+			"did:sidetree": &mockResolver{err: errors.New("bad request: invalid suffix")},
+		}})
+
+		rr = serveHTTP(t, getHandler(t, c, identifiersEndpoint).Handler(), did, "")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		requireError(t, rr, restapi.ErrInvalidDID)
+	})
+
+	t.Run("method not supported", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handler(), "did:other:suffix", "")
+		require.Equal(t, http.StatusNotImplemented, rr.Code)
+		requireError(t, rr, restapi.ErrMethodNotSupported)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{
+			"did:sidetree": &mockResolver{err: errors.New("uniqueSuffix not found in the store")},
+		}})
+
+		rr := serveHTTP(t, getHandler(t, c, identifiersEndpoint).Handler(), did, restapi.DIDLDJSONContentType)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		requireError(t, rr, restapi.ErrNotFound)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{
+			"did:sidetree": &mockResolver{err: errors.New("injected error")},
+		}})
+
+		rr := serveHTTP(t, getHandler(t, c, identifiersEndpoint).Handler(), did, "")
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		requireError(t, rr, restapi.ErrInternal)
+	})
+}
+
+func TestDereference(t *testing.T) {
+	resolver := &mockResolver{
+		result: &document.ResolutionResult{
+			Document: document.Document{
+				"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+				"id":       did,
+				"verificationMethod": []interface{}{
+					map[string]interface{}{"id": "#key-1", "type": "JsonWebKey2020", "controller": did},
+				},
+				"authentication": []interface{}{
+					"#key-1",
+					map[string]interface{}{"id": did + "#auth-key", "type": "JsonWebKey2020"},
+				},
+				"service": []interface{}{
+					map[string]interface{}{"id": did + "#hub-1", "type": "hub", "serviceEndpoint": "https://hub.example.com/base/"},
+					map[string]interface{}{"id": "#hub-2", "type": "hub", "serviceEndpoint": map[string]interface{}{"origins": []interface{}{"https://example.com"}}},
+				},
+			},
+			DocumentMetadata: document.Metadata{"canonicalId": did},
+		},
+	}
+
+	c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{"did:sidetree": resolver}})
+
+	handler := getHandler(t, c, identifiersEndpoint).Handler()
+
+	t.Run("verification method", func(t *testing.T) {
+		for _, fragment := range []string{"key-1", "auth-key"} {
+			rr := serveDereference(t, handler, did+"#"+fragment, "", "")
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, restapi.DIDLDJSONContentType, rr.Header().Get("Content-Type"))
+
+			obj := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &obj))
+			require.Contains(t, obj["id"], "#"+fragment)
+			require.Equal(t, []interface{}{"https://www.w3.org/ns/did/v1"}, obj["@context"])
+		}
+	})
+
+	t.Run("service by fragment", func(t *testing.T) {
+		rr := serveDereference(t, handler, did+"#hub-2", "", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), "origins")
+	})
+
+	t.Run("dereferencing result", func(t *testing.T) {
+		rr := serveDereference(t, handler, did+"#key-1", "", restapi.DereferencingResultContentType)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, restapi.DereferencingResultContentType, rr.Header().Get("Content-Type"))
+
+		result := &restapi.DereferencingResult{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+		require.Equal(t, restapi.DIDLDJSONContentType, result.DereferencingMetadata.ContentType)
+		require.Equal(t, "#key-1", result.ContentStream.(map[string]interface{})["id"])
+		require.Equal(t, did, result.ContentMetadata["canonicalId"])
+	})
+
+	t.Run("service redirect", func(t *testing.T) {
+		for didURL, location := range map[string]string{
+			did + "?service=hub-1":                           "https://hub.example.com/base/",
+			did + "?service=hub-1&relativeRef=/path":         "https://hub.example.com/path",
+			did + "?service=hub-1&relativeRef=path%3Fq%3D1":  "https://hub.example.com/base/path?q=1",
+			did + "?service=hub-1&relativeRef=path#fragment": "https://hub.example.com/base/path#fragment",
+		} {
+			path, query := didURL, ""
+			if i := strings.Index(didURL, "?"); i >= 0 {
+				path, query = didURL[:i], didURL[i+1:]
+			}
+
+			if i := strings.Index(query, "#"); i >= 0 {
+				path, query = path+query[i:], query[:i]
+			}
+
+			rr := serveDereference(t, handler, path, query, "")
+			require.Equal(t, http.StatusSeeOther, rr.Code, didURL)
+			require.Equal(t, location, rr.Header().Get("Location"), didURL)
+
+			// the query may also be percent-encoded in the path
+			rr = serveDereference(t, handler, path[:len(did)]+"?"+query+path[len(did):], "", "")
+			require.Equal(t, http.StatusSeeOther, rr.Code, didURL)
+			require.Equal(t, location, rr.Header().Get("Location"), didURL)
+		}
+
+		rr := serveDereference(t, handler, did, "service=hub-1&relativeRef=/path",
+			restapi.DereferencingResultContentType)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		result := &restapi.DereferencingResult{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+		require.Equal(t, "https://hub.example.com/path", result.ContentStream)
+	})
+
+	t.Run("transformed document", func(t *testing.T) {
+		// the document transformer builds typed lists of verification methods and services
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{"did:sidetree": &mockResolver{
+			result: &document.ResolutionResult{
+				Document: document.Document{
+					"id": did,
+					"verificationMethod": []document.PublicKey{
+						{"id": did + "#key-1", "type": "JsonWebKey2020"},
+					},
+					"keyAgreement": []interface{}{document.PublicKey{"id": did + "#ka-key"}},
+					"service": []document.Service{
+						{"id": did + "#hub-1", "type": "hub", "serviceEndpoint": "https://hub.example.com"},
+					},
+				},
+			},
+		}}})
+
+		handler := getHandler(t, c, identifiersEndpoint).Handler()
+
+		for _, fragment := range []string{"key-1", "ka-key", "hub-1"} {
+			rr := serveDereference(t, handler, did+"#"+fragment, "", "")
+			require.Equal(t, http.StatusOK, rr.Code, fragment)
+			require.Contains(t, rr.Body.String(), did+"#"+fragment)
+		}
+
+		rr := serveDereference(t, handler, did, "service=hub-1", "")
+		require.Equal(t, http.StatusSeeOther, rr.Code)
+		require.Equal(t, "https://hub.example.com", rr.Header().Get("Location"))
+	})
+
+	t.Run("service endpoint isn't a URL", func(t *testing.T) {
+		rr := serveDereference(t, handler, did, "service=hub-2", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), "origins")
+
+		rr = serveDereference(t, handler, did, "service=hub-2&relativeRef=/path", "")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		requireDereferencingError(t, rr, restapi.ErrInvalidDIDURL)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := serveDereference(t, handler, did+"#key-2", "", "")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		requireDereferencingError(t, rr, restapi.ErrNotFound)
+
+		rr = serveDereference(t, handler, did, "service=hub-3", "")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		requireDereferencingError(t, rr, restapi.ErrNotFound)
+
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{
+			"did:sidetree": &mockResolver{err: errors.New("uniqueSuffix not found in the store")},
+		}})
+
+		rr = serveDereference(t, getHandler(t, c, identifiersEndpoint).Handler(), did+"#key-1", "", "")
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		requireDereferencingError(t, rr, restapi.ErrNotFound)
+	})
+
+	t.Run("invalid DID URL", func(t *testing.T) {
+		for _, query := range []string{
+			"relativeRef=/path",
+			"service=hub-1&relativeRef=https://other.com",
+			"service=hub-1&versionId=1&versionTime=2021-01-01T00:00:00Z",
+		} {
+			rr := serveDereference(t, handler, did, query, "")
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+			requireDereferencingError(t, rr, restapi.ErrInvalidDIDURL)
+		}
+
+		rr := serveDereference(t, handler, did, "service=%zz", "")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("deactivated", func(t *testing.T) {
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{"did:sidetree": &mockResolver{
+			result: &document.ResolutionResult{
+				Document:         document.Document{"id": did},
+				DocumentMetadata: document.Metadata{"deactivated": true},
+			},
+		}}})
+
+		rr := serveDereference(t, getHandler(t, c, identifiersEndpoint).Handler(), did+"#key-1", "", "")
+		require.Equal(t, http.StatusGone, rr.Code)
+
+		result := &restapi.DereferencingResult{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+		require.Equal(t, true, result.ContentMetadata["deactivated"])
+	})
+
+	t.Run("representation not supported", func(t *testing.T) {
+		rr := serveDereference(t, handler, did+"#key-1", "", restapi.ResolutionResultContentType)
+		require.Equal(t, http.StatusNotAcceptable, rr.Code)
+		requireDereferencingError(t, rr, restapi.ErrRepresentationNotSupported)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		versionResolver := &mockResolver{result: resolver.result}
+
+		c := restapi.New(&restapi.Config{Resolvers: map[string]restapi.Resolver{"did:sidetree": versionResolver}})
+
+		rr := serveDereference(t, getHandler(t, c, identifiersEndpoint).Handler(), did+"#key-1", "versionId=v1", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		opts, err := document.GetResolutionOptions(versionResolver.opts...)
+		require.NoError(t, err)
+		require.Equal(t, "v1", opts.VersionID)
+
+		rr = serveDereference(t, getHandler(t, c, identifiersEndpoint).Handler(), did,
+			"versionTime=2021-01-01T00:00:00Z", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		opts, err = document.GetResolutionOptions(versionResolver.opts...)
+		require.NoError(t, err)
+		require.Equal(t, "2021-01-01T00:00:00Z", opts.VersionTime)
+	})
+}
+
+type mockResolver struct {
+	result *document.ResolutionResult
+	err    error
+	opts   []document.ResolutionOption
+}
+
+func (m *mockResolver) ResolveDocument(_ string,
+	opts ...document.ResolutionOption) (*document.ResolutionResult, error) {
+	m.opts = opts
+
+	return m.result, m.err
+}
+
+func requireError(t *testing.T, rr *httptest.ResponseRecorder, code string) {
+	t.Helper()
+
+	require.Equal(t, restapi.ResolutionResultContentType, rr.Header().Get("Content-Type"))
+
+	result := &restapi.ResolutionResult{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+	require.Equal(t, code, result.ResolutionMetadata.Error)
+	require.NotEmpty(t, result.ResolutionMetadata.ErrorMessage)
+	require.Nil(t, result.Document)
+	require.NotNil(t, result.DocumentMetadata)
+}
+
+func requireDereferencingError(t *testing.T, rr *httptest.ResponseRecorder, code string) {
+	t.Helper()
+
+	require.Equal(t, restapi.DereferencingResultContentType, rr.Header().Get("Content-Type"))
+
+	result := &restapi.DereferencingResult{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), result))
+	require.Equal(t, code, result.DereferencingMetadata.Error)
+	require.NotEmpty(t, result.DereferencingMetadata.ErrorMessage)
+	require.Nil(t, result.ContentStream)
+}
+
+// serveDereference serves a request for the given DID URL. The path and the fragment of the DID URL are passed in
+// the path (percent-encoded) and the query of the DID URL may be passed in the path or in the query of the request.
+func serveDereference(t *testing.T, handler common.HTTPRequestHandler, didURL, rawQuery,
+	accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(http.MethodGet, "/1.0/identifiers/"+url.PathEscape(didURL)+"?"+rawQuery, nil)
+	require.NoError(t, err)
+
+	if accept != "" {
+		httpReq.Header.Set("Accept", accept)
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler(rr, mux.SetURLVars(httpReq, map[string]string{"did": didURL}))
+
+	return rr
+}
+
+func serveHTTP(t *testing.T, handler common.HTTPRequestHandler, did, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(http.MethodGet, "/1.0/identifiers/"+did, nil)
+	require.NoError(t, err)
+
+	if accept != "" {
+		httpReq.Header.Set("Accept", accept)
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler(rr, mux.SetURLVars(httpReq, map[string]string{"did": did}))
+
+	return rr
+}
+
+func getHandler(t *testing.T, op *restapi.Operation, lookup string) common.HTTPHandler {
+	t.Helper()
+
+	for _, h := range op.GetRESTHandlers() {
+		if h.Path() == lookup {
+			return h
+		}
+	}
+
+	require.Fail(t, "unable to find handler")
+
+	return nil
+}
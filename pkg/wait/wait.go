@@ -0,0 +1,365 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wait
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/sidetree-mock/pkg/representation"
+)
+
+var logger = log.New("wait")
+
+const (
+	// Header asks to wait until the operation of the request is visible through resolution. The value is either
+	// true (wait for the default timeout) or the maximum time to wait (e.g. 10s).
+	Header = "X-Sidetree-Wait"
+	// Param may be used instead of the header.
+	Param = "wait"
+
+	// TransactionNumberHeader holds the number of the transaction that the operation was anchored in.
+	TransactionNumberHeader = "X-Sidetree-Transaction-Number"
+	// TransactionTimeHeader holds the time of the transaction that the operation was anchored in.
+	TransactionTimeHeader = "X-Sidetree-Transaction-Time"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultPollInterval = 100 * time.Millisecond
+)
+
+// OperationStore returns the anchored operations of a DID
+type OperationStore interface {
+	Get(suffix string) ([]*operation.AnchoredOperation, error)
+}
+
+// Ledger holds the transactions that anchored the operations
+type Ledger interface {
+	Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn)
+}
+
+// Resolver resolves DID documents
+type Resolver interface {
+	ResolveDocument(id string, opts ...document.ResolutionOption) (*document.ResolutionResult, error)
+}
+
+// Waiter waits for the operations of a namespace to become visible through resolution
+type Waiter struct {
+	namespace    string
+	pc           protocol.Client
+	store        OperationStore
+	ledger       Ledger
+	resolver     Resolver
+	timeout      time.Duration
+	pollInterval time.Duration
+}
+
+// Option is a waiter option
+type Option func(w *Waiter)
+
+// WithTimeout sets the default and maximum time to wait for an operation
+func WithTimeout(timeout time.Duration) Option {
+	return func(w *Waiter) {
+		w.timeout = timeout
+	}
+}
+
+// WithPollInterval sets the interval at which the operation store is checked for the operation
+func WithPollInterval(interval time.Duration) Option {
+	return func(w *Waiter) {
+		w.pollInterval = interval
+	}
+}
+
+// New returns a new waiter for the operations of the given namespace
+func New(namespace string, pc protocol.Client, store OperationStore, ledger Ledger, resolver Resolver,
+	opts ...Option) *Waiter {
+	w := &Waiter{
+		namespace:    namespace,
+		pc:           pc,
+		store:        store,
+		ledger:       ledger,
+		resolver:     resolver,
+		timeout:      defaultTimeout,
+		pollInterval: defaultPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// NewHandler returns a handler that passes requests to the given operation handler. If the request asks to wait
+// (see Header and Param) and the operation was accepted then the response is held back until the operation was
+// anchored and processed by the observer so that it is visible through resolution. The response then holds the
+// number and time of the transaction that the operation was anchored in (see TransactionNumberHeader and
+// TransactionTimeHeader). If the operation isn't visible within the timeout then 202 is returned instead of 200.
+func (w *Waiter) NewHandler(operationHandler common.HTTPRequestHandler) common.HTTPRequestHandler {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		timeout, ok, err := w.getTimeout(req)
+		if err != nil {
+			common.WriteError(rw, http.StatusBadRequest, err)
+
+			return
+		}
+
+		if !ok {
+			operationHandler(rw, req)
+
+			return
+		}
+
+		request, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			common.WriteError(rw, http.StatusBadRequest, fmt.Errorf("read request: %w", err))
+
+			return
+		}
+
+		req.Body = ioutil.NopCloser(bytes.NewReader(request))
+
+		// the operation handler queues the operation under the current protocol version
+		pv, err := w.pc.Current()
+		if err != nil {
+			common.WriteError(rw, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		resp := representation.NewResponseBuffer()
+
+		operationHandler(resp, req)
+
+		// errors are returned as is
+		if resp.Status != http.StatusOK {
+			resp.CopyTo(rw)
+
+			return
+		}
+
+		op, err := parse(w.namespace, pv, request)
+		if err != nil {
+			// the operation was accepted so this shouldn't happen
+			logger.Warnf("Unable to wait for operation: %s", err)
+
+			resp.CopyTo(rw)
+
+			return
+		}
+
+		anchoredOp := w.wait(req.Context(), op, timeout)
+		if anchoredOp == nil {
+			logger.Debugf("[%s] %s operation isn't visible after %s", op.uniqueSuffix, op.opType, timeout)
+
+			resp.Status = http.StatusAccepted
+			resp.CopyTo(rw)
+
+			return
+		}
+
+		resp.Header().Set(TransactionNumberHeader, strconv.FormatUint(anchoredOp.TransactionNumber, 10))
+		resp.Header().Set(TransactionTimeHeader, strconv.FormatUint(anchoredOp.TransactionTime, 10))
+		resp.CopyTo(rw)
+	}
+}
+
+// getTimeout returns the time to wait for the operation of the request. False is returned if the request doesn't
+// ask to wait.
+func (w *Waiter) getTimeout(req *http.Request) (time.Duration, bool, error) {
+	value := req.Header.Get(Header)
+	if value == "" {
+		value = req.URL.Query().Get(Param)
+	}
+
+	if value == "" {
+		return 0, false, nil
+	}
+
+	if wait, err := strconv.ParseBool(value); err == nil {
+		return w.timeout, wait, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, false, fmt.Errorf("invalid wait [%s]: must be true, false or a positive duration", value)
+	}
+
+	if timeout > w.timeout {
+		timeout = w.timeout
+	}
+
+	return timeout, true, nil
+}
+
+// pendingOperation is an operation that was accepted by the node
+type pendingOperation struct {
+	request      []byte
+	opType       operation.Type
+	uniqueSuffix string
+	revealValue  string
+	commitment   string
+}
+
+// parse parses the operation request with the given protocol version
+func parse(namespace string, pv protocol.Version, request []byte) (*pendingOperation, error) {
+	parser := pv.OperationParser()
+
+	op, err := parser.Parse(namespace, request)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &pendingOperation{request: request, opType: op.Type, uniqueSuffix: op.UniqueSuffix}
+
+	if op.Type == operation.TypeCreate {
+		return pending, nil
+	}
+
+	pending.revealValue, err = parser.GetRevealValue(request)
+	if err != nil {
+		return nil, err
+	}
+
+	pending.commitment, err = parser.GetCommitment(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// wait returns the anchored operation once the operation is visible through resolution. Nil is returned if the
+// timeout elapses or the request is cancelled first.
+func (w *Waiter) wait(ctx context.Context, op *pendingOperation, timeout time.Duration) *operation.AnchoredOperation {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if anchoredOp := w.visible(op); anchoredOp != nil {
+			return anchoredOp
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// visible returns the anchored operation if the operation was processed into the operation store and the
+// document resolved at the version created by the operation reflects the operation
+func (w *Waiter) visible(op *pendingOperation) *operation.AnchoredOperation {
+	anchoredOps, err := w.store.Get(op.uniqueSuffix)
+	if err != nil {
+		// the operation wasn't processed yet
+		return nil
+	}
+
+	for _, anchoredOp := range anchoredOps {
+		anchored, ok := w.matches(op, anchoredOp)
+		if !ok {
+			continue
+		}
+
+		// the version created by the operation is resolved since later operations may change the document
+		var opts []document.ResolutionOption
+		if _, t := w.ledger.Read(int(anchoredOp.TransactionNumber) - 1); t != nil && t.CanonicalReference != "" {
+			opts = append(opts, document.WithVersionID(t.CanonicalReference))
+		}
+
+		result, err := w.resolver.ResolveDocument(w.namespace+":"+op.uniqueSuffix, opts...)
+		if err != nil {
+			logger.Debugf("[%s] unable to resolve document: %s", op.uniqueSuffix, err)
+
+			continue
+		}
+
+		if applied(anchored, result) {
+			return anchoredOp
+		}
+	}
+
+	return nil
+}
+
+// matches returns true if the anchored operation is the given operation. There is only one create operation for
+// a DID and the reveal value of the other operations is unique. The operation is parsed with the protocol version
+// that the anchored operation was anchored under and the parsed operation is returned.
+func (w *Waiter) matches(op *pendingOperation,
+	anchoredOp *operation.AnchoredOperation) (*pendingOperation, bool) {
+	if anchoredOp.Type != op.opType {
+		return nil, false
+	}
+
+	pv, err := w.pc.Get(anchoredOp.ProtocolVersion)
+	if err != nil {
+		logger.Warnf("[%s] unable to get protocol version %d: %s", op.uniqueSuffix, anchoredOp.ProtocolVersion, err)
+
+		return nil, false
+	}
+
+	anchored, err := parse(w.namespace, pv, op.request)
+	if err != nil {
+		logger.Warnf("[%s] unable to parse operation with protocol version %d: %s", op.uniqueSuffix,
+			anchoredOp.ProtocolVersion, err)
+
+		return nil, false
+	}
+
+	if op.opType == operation.TypeCreate {
+		return anchored, true
+	}
+
+	revealValue, err := pv.OperationParser().GetRevealValue(anchoredOp.OperationRequest)
+	if err != nil {
+		logger.Warnf("[%s] unable to get reveal value of anchored operation: %s", op.uniqueSuffix, err)
+
+		return nil, false
+	}
+
+	return anchored, revealValue == anchored.revealValue
+}
+
+// applied returns true if the resolution result reflects the given operation. Operations that were anchored but
+// couldn't be applied (e.g. because the signature is invalid) are never visible.
+func applied(op *pendingOperation, result *document.ResolutionResult) bool {
+	methodMetadata, _ := result.DocumentMetadata[document.MethodProperty].(document.Metadata)
+
+	switch op.opType {
+	case operation.TypeCreate:
+		return true
+	case operation.TypeUpdate:
+		return methodMetadata[document.UpdateCommitmentProperty] == op.commitment
+	case operation.TypeRecover:
+		return methodMetadata[document.RecoveryCommitmentProperty] == op.commitment
+	case operation.TypeDeactivate:
+		deactivated, ok := result.DocumentMetadata[document.DeactivatedProperty].(bool)
+
+		return ok && deactivated
+	default:
+		return false
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wait
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-core-go/pkg/dochandler"
+	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+	coremocks "github.com/trustbloc/sidetree-core-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-core-go/pkg/patch"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/diddochandler"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/ecsigner"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
+
+	"github.com/trustbloc/sidetree-mock/pkg/history"
+	"github.com/trustbloc/sidetree-mock/pkg/mocks"
+)
+
+const (
+	namespace = "did:sidetree"
+	sha2_256  = 18
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("create", func(t *testing.T) {
+		n := newNode(t)
+
+		go n.process(t, operation.TypeCreate, n.create, 3, 2, "ref1")
+
+		rw := n.post(t, n.create, "?wait=true", "")
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "3", rw.Header().Get(TransactionNumberHeader))
+		require.Equal(t, "2", rw.Header().Get(TransactionTimeHeader))
+		require.Contains(t, rw.Body.String(), "didDocument")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		n := newNode(t)
+		n.process(t, operation.TypeCreate, n.create, 0, 0, "ref1")
+
+		update := n.newUpdateRequest(t)
+
+		go n.process(t, operation.TypeUpdate, update, 1, 1, "ref2")
+
+		rw := n.post(t, update, "", "5s")
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "1", rw.Header().Get(TransactionNumberHeader))
+		require.Equal(t, "1", rw.Header().Get(TransactionTimeHeader))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		n := newNode(t, WithTimeout(50*time.Millisecond))
+
+		start := time.Now()
+
+		// the requested timeout is limited to the maximum timeout
+		rw := n.post(t, n.create, "?wait=1h", "")
+		require.Equal(t, http.StatusAccepted, rw.Code)
+		require.Empty(t, rw.Header().Get(TransactionNumberHeader))
+		require.Contains(t, rw.Body.String(), "didDocument")
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("not applied", func(t *testing.T) {
+		n := newNode(t, WithTimeout(200*time.Millisecond))
+		n.process(t, operation.TypeCreate, n.create, 0, 0, "ref1")
+
+		update := n.newUpdateRequest(t)
+
+		// another update that was anchored with the same reveal value is applied instead
+		n.process(t, operation.TypeUpdate, n.newUpdateRequest(t), 1, 1, "ref2")
+
+		go n.process(t, operation.TypeUpdate, update, 2, 2, "ref3")
+
+		rw := n.post(t, update, "", "true")
+		require.Equal(t, http.StatusAccepted, rw.Code)
+	})
+
+	t.Run("no wait", func(t *testing.T) {
+		n := newNode(t)
+
+		for _, query := range []string{"", "?wait=false"} {
+			rw := n.post(t, n.create, query, "")
+			require.Equal(t, http.StatusOK, rw.Code)
+			require.Empty(t, rw.Header().Get(TransactionNumberHeader))
+			require.Contains(t, rw.Body.String(), "didDocument")
+		}
+	})
+
+	t.Run("invalid wait", func(t *testing.T) {
+		n := newNode(t)
+
+		for _, value := range []string{"abc", "-1s", "0s"} {
+			rw := n.post(t, n.create, "", value)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+			require.Contains(t, rw.Body.String(), "invalid wait")
+		}
+	})
+
+	t.Run("operation error", func(t *testing.T) {
+		n := newNode(t)
+
+		rw := n.post(t, []byte("{}"), "?wait=true", "")
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Empty(t, rw.Header().Get(TransactionNumberHeader))
+	})
+}
+
+type node struct {
+	store        *mocks.MockOperationStore
+	ledger       *ledger
+	pc           protocol.Client
+	handler      common.HTTPRequestHandler
+	create       []byte
+	updateKey    *ecdsa.PrivateKey
+	updateJWK    *jws.JWK
+	uniqueSuffix string
+}
+
+func newNode(t *testing.T, opts ...Option) *node {
+	t.Helper()
+
+	store := mocks.NewMockOperationStore()
+	l := &ledger{txns: make(map[uint64]*txn.SidetreeTxn)}
+
+	pc, err := mocks.NewMockProtocolClientProvider().WithOpStore(store).WithOpStoreClient(store).
+		ForNamespace(namespace)
+	require.NoError(t, err)
+
+	docHandler := dochandler.New(namespace, nil, pc, &batchWriter{}, history.New(namespace, store, pc, l),
+		&coremocks.MetricsProvider{})
+
+	updateHandler := diddochandler.NewUpdateHandler("/operations", docHandler, pc, &coremocks.MetricsProvider{})
+
+	opts = append([]Option{WithPollInterval(5 * time.Millisecond)}, opts...)
+
+	n := &node{
+		store:   store,
+		ledger:  l,
+		pc:      pc,
+		handler: New(namespace, pc, store, l, docHandler, opts...).NewHandler(updateHandler.Handler()),
+	}
+
+	n.updateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	n.updateJWK, err = pubkey.GetPublicKeyJWK(&n.updateKey.PublicKey)
+	require.NoError(t, err)
+
+	updateCommitment, err := commitment.GetCommitment(n.updateJWK, sha2_256)
+	require.NoError(t, err)
+
+	recoveryCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x", Y: "y"}, sha2_256)
+	require.NoError(t, err)
+
+	n.create, err = client.NewCreateRequest(&client.CreateRequestInfo{
+		OpaqueDocument:     validDoc,
+		RecoveryCommitment: recoveryCommitment,
+		UpdateCommitment:   updateCommitment,
+		MultihashCode:      sha2_256,
+	})
+	require.NoError(t, err)
+
+	pv, err := pc.Current()
+	require.NoError(t, err)
+
+	op, err := pv.OperationParser().Parse(namespace, n.create)
+	require.NoError(t, err)
+
+	n.uniqueSuffix = op.UniqueSuffix
+
+	return n
+}
+
+func (n *node) post(t *testing.T, request []byte, query, header string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/operations"+query, bytes.NewReader(request))
+	if header != "" {
+		req.Header.Set(Header, header)
+	}
+
+	rw := httptest.NewRecorder()
+
+	n.handler(rw, req)
+
+	return rw
+}
+
+// process adds the transaction to the ledger and the operation to the operation store as the observer would after
+// a short delay
+func (n *node) process(t *testing.T, opType operation.Type, request []byte, txnNumber, txnTime uint64,
+	ref string) {
+	time.Sleep(20 * time.Millisecond)
+
+	n.ledger.add(&txn.SidetreeTxn{
+		Namespace:          namespace,
+		TransactionNumber:  txnNumber,
+		TransactionTime:    txnTime,
+		CanonicalReference: ref,
+	})
+
+	assert.NoError(t, n.store.Put([]*operation.AnchoredOperation{{
+		Type:              opType,
+		UniqueSuffix:      n.uniqueSuffix,
+		OperationRequest:  request,
+		TransactionNumber: txnNumber,
+		TransactionTime:   txnTime,
+	}}))
+}
+
+// newUpdateRequest returns a request to update the created DID with the update key of the created DID
+func (n *node) newUpdateRequest(t *testing.T) []byte {
+	t.Helper()
+
+	revealValue, err := commitment.GetRevealValue(n.updateJWK, sha2_256)
+	require.NoError(t, err)
+
+	nextKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	nextJWK, err := pubkey.GetPublicKeyJWK(&nextKey.PublicKey)
+	require.NoError(t, err)
+
+	nextCommitment, err := commitment.GetCommitment(nextJWK, sha2_256)
+	require.NoError(t, err)
+
+	p, err := patch.NewAddServiceEndpointsPatch(
+		`[{"id":"svc1","type":"type","serviceEndpoint":"https://example.com"}]`)
+	require.NoError(t, err)
+
+	request, err := client.NewUpdateRequest(&client.UpdateRequestInfo{
+		DidSuffix:        n.uniqueSuffix,
+		Patches:          []patch.Patch{p},
+		UpdateCommitment: nextCommitment,
+		UpdateKey:        n.updateJWK,
+		MultihashCode:    sha2_256,
+		Signer:           ecsigner.New(n.updateKey, "ES256", ""),
+		RevealValue:      revealValue,
+	})
+	require.NoError(t, err)
+
+	return request
+}
+
+type ledger struct {
+	mutex sync.Mutex
+	txns  map[uint64]*txn.SidetreeTxn
+}
+
+func (l *ledger) add(t *txn.SidetreeTxn) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.txns[t.TransactionNumber] = t
+}
+
+func (l *ledger) Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return false, l.txns[uint64(sinceTransactionNumber+1)]
+}
+
+func (l *ledger) Transactions() []*txn.SidetreeTxn {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	txns := make([]*txn.SidetreeTxn, 0, len(l.txns))
+	for _, t := range l.txns {
+		txns = append(txns, t)
+	}
+
+	return txns
+}
+
+type batchWriter struct{}
+
+func (w *batchWriter) Add(*operation.QueuedOperation, uint64) error {
+	return nil
+}
+
+const validDoc = `{
+	"publicKey": [{
+		"id": "key-1",
+		"purposes": ["authentication"],
+		"type": "JsonWebKey2020",
+		"publicKeyJwk": {
+			"kty": "EC",
+			"crv": "P-256K",
+			"x": "PUymIqdtF_qxaAqPABSw-C-owT1KYYQbsMKFM-L9fJA",
+			"y": "nM84jDHCMOTGTh_ZdHq4dBBdo4Z5PkEOW9jA8z8IsGc"
+		}
+	}]
+}`
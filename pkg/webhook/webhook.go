@@ -0,0 +1,498 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-mock/pkg/opinfo"
+)
+
+var logger = logrus.New()
+
+// EventType is the type of event that is delivered to webhooks.
+type EventType string
+
+const (
+	// OperationQueued is delivered when operations are accepted and queued by the batch writer.
+	OperationQueued EventType = "operation.queued"
+	// OperationAnchored is delivered when the batch writer has written the anchor of a batch holding operations.
+	OperationAnchored EventType = "operation.anchored"
+	// OperationProcessed is delivered when the observer has processed operations into the operation store, at
+	// which point the operations are visible through resolution.
+	OperationProcessed EventType = "operation.processed"
+)
+
+// HTTP headers of a webhook delivery.
+const (
+	// EventHeader holds the type of the event.
+	EventHeader = "X-Sidetree-Event"
+	// DeliveryHeader holds the ID of the event. The ID is the same for all attempts to deliver the event.
+	DeliveryHeader = "X-Sidetree-Delivery"
+	// SignatureHeader holds the HMAC-SHA256 of the request body keyed with the secret of the subscription
+	// ("sha256=" followed by the hex-encoded MAC).
+	SignatureHeader = "X-Sidetree-Signature"
+	// AttemptHeader holds the number of the delivery attempt (starting at 1).
+	AttemptHeader = "X-Sidetree-Delivery-Attempt"
+)
+
+const signaturePrefix = "sha256="
+
+// operationTypes are the operation types that subscriptions may filter on
+var operationTypes = []operation.Type{
+	operation.TypeCreate, operation.TypeUpdate, operation.TypeRecover, operation.TypeDeactivate,
+}
+
+const (
+	defaultMaxAttempts   = 5
+	defaultRetryInterval = time.Second
+	defaultTimeout       = 10 * time.Second
+
+	// maxRetryInterval caps the exponential backoff between delivery attempts
+	maxRetryInterval = time.Minute
+
+	// queueSize is the number of events that may wait for delivery to a subscription. Events are dropped if
+	// the endpoint of the subscription can't keep up.
+	queueSize = 1000
+
+	secretSize = 32
+	idSize     = 16
+)
+
+// Subscription is a webhook subscription. The endpoint at URL is invoked for every event that holds operations
+// matching the filters of the subscription (if any).
+type Subscription struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret is the key of the HMAC that signs the payloads. A secret is generated if none is provided when
+	// subscribing. The secret is only returned when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+	// Suffixes limits the events to operations on the DIDs with the given unique suffixes
+	Suffixes []string `json:"suffixes,omitempty"`
+	// OperationTypes limits the events to operations of the given types
+	OperationTypes []operation.Type `json:"operationTypes,omitempty"`
+	Created        time.Time        `json:"created"`
+}
+
+// Event is the payload that is posted to the endpoint of a subscription.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace"`
+	// Anchor is the anchor string of the batch (operation.anchored only)
+	Anchor     string       `json:"anchor,omitempty"`
+	Operations []*Operation `json:"operations"`
+}
+
+// Operation is an operation in an event. The transaction fields are only set for operation.processed events.
+type Operation = opinfo.Operation
+
+// Dispatcher delivers the events of the node to the endpoints of webhook subscriptions. Each subscription has its
+// own delivery queue so that a slow or failing endpoint doesn't delay the deliveries to other subscriptions. Events
+// are delivered to an endpoint in order; a delivery that fails (the request fails or the endpoint doesn't respond
+// with a 2xx status) is retried with exponential backoff until the maximum number of attempts is reached.
+//
+// Subscriptions are kept in memory and are lost on restart.
+type Dispatcher struct {
+	mutex         sync.RWMutex
+	subscriptions map[string]*subscriber
+	client        *http.Client
+	maxAttempts   int
+	retryInterval time.Duration
+	ledger        opinfo.Ledger
+}
+
+// Option is a dispatcher option
+type Option func(d *Dispatcher)
+
+// WithMaxAttempts sets the maximum number of attempts to deliver an event
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+	}
+}
+
+// WithRetryInterval sets the time to wait before the first retry of a failed delivery. The interval is doubled for
+// every further retry.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.retryInterval = interval
+	}
+}
+
+// WithTimeout sets the timeout of a delivery request
+func WithTimeout(timeout time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.client = &http.Client{Timeout: timeout}
+	}
+}
+
+// WithLedger sets the ledger from which the canonical references of the transactions of processed operations
+// are taken
+func WithLedger(ledger opinfo.Ledger) Option {
+	return func(d *Dispatcher) {
+		d.ledger = ledger
+	}
+}
+
+// New returns a new webhook dispatcher
+func New(opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		subscriptions: make(map[string]*subscriber),
+		client:        &http.Client{Timeout: defaultTimeout},
+		maxAttempts:   defaultMaxAttempts,
+		retryInterval: defaultRetryInterval,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Subscribe registers the given subscription. The registered subscription (with its ID and secret) is returned.
+func (d *Dispatcher) Subscribe(s *Subscription) (*Subscription, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook URL [%s] must be an absolute http or https URL", s.URL)
+	}
+
+	for _, t := range s.OperationTypes {
+		if !containsType(operationTypes, t) {
+			return nil, fmt.Errorf("operation type [%s] is not supported", t)
+		}
+	}
+
+	id, err := random(idSize)
+	if err != nil {
+		return nil, fmt.Errorf("generate subscription ID: %w", err)
+	}
+
+	sub := &Subscription{
+		ID:             hex.EncodeToString(id),
+		URL:            s.URL,
+		Secret:         s.Secret,
+		Suffixes:       s.Suffixes,
+		OperationTypes: s.OperationTypes,
+		Created:        time.Now().UTC(),
+	}
+
+	if sub.Secret == "" {
+		secret, e := random(secretSize)
+		if e != nil {
+			return nil, fmt.Errorf("generate secret: %w", e)
+		}
+
+		sub.Secret = base64.RawURLEncoding.EncodeToString(secret)
+	}
+
+	sr := &subscriber{
+		Subscription: sub,
+		dispatcher:   d,
+		events:       make(chan *Event, queueSize),
+		stopCh:       make(chan struct{}),
+	}
+
+	d.mutex.Lock()
+	d.subscriptions[sub.ID] = sr
+	d.mutex.Unlock()
+
+	go sr.deliverAll()
+
+	logger.Infof("webhook [%s] subscribed at [%s]", sub.ID, sub.URL)
+
+	result := *sub
+
+	return &result, nil
+}
+
+// Unsubscribe removes the subscription with the given ID. Events that weren't delivered yet are discarded.
+// False is returned if there is no such subscription.
+func (d *Dispatcher) Unsubscribe(id string) bool {
+	d.mutex.Lock()
+	sr, ok := d.subscriptions[id]
+	delete(d.subscriptions, id)
+	d.mutex.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	close(sr.stopCh)
+
+	logger.Infof("webhook [%s] unsubscribed", id)
+
+	return true
+}
+
+// Subscriptions returns all subscriptions ordered by creation time. Secrets are not included.
+func (d *Dispatcher) Subscriptions() []*Subscription {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	subs := make([]*Subscription, 0, len(d.subscriptions))
+
+	for _, sr := range d.subscriptions {
+		sub := *sr.Subscription
+		sub.Secret = ""
+
+		subs = append(subs, &sub)
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Created.Equal(subs[j].Created) {
+			return subs[i].ID < subs[j].ID
+		}
+
+		return subs[i].Created.Before(subs[j].Created)
+	})
+
+	return subs
+}
+
+// OperationQueued delivers an operation.queued event for the given operation (implements batchwriter.Listener)
+func (d *Dispatcher) OperationQueued(namespace string, op *operation.QueuedOperation) {
+	d.dispatch(&Event{
+		Type:       OperationQueued,
+		Namespace:  namespace,
+		Operations: []*Operation{opinfo.NewQueued(namespace, op)},
+	})
+}
+
+// BatchAnchored delivers an operation.anchored event for the operations of the batch with the given anchor
+// (implements batchwriter.Listener)
+func (d *Dispatcher) BatchAnchored(namespace, anchor string, refs []*operation.Reference) {
+	ops := make([]*Operation, len(refs))
+
+	for i, ref := range refs {
+		ops[i] = opinfo.NewAnchored(namespace, ref)
+	}
+
+	d.dispatch(&Event{
+		Type:       OperationAnchored,
+		Namespace:  namespace,
+		Anchor:     anchor,
+		Operations: ops,
+	})
+}
+
+// OperationsProcessed delivers an operation.processed event for the given operations that were processed by the
+// observer into the operation store of the namespace
+func (d *Dispatcher) OperationsProcessed(namespace string, anchoredOps []*operation.AnchoredOperation) {
+	ops := make([]*Operation, len(anchoredOps))
+
+	for i, op := range anchoredOps {
+		ops[i] = opinfo.NewProcessed(namespace, op, d.ledger)
+	}
+
+	d.dispatch(&Event{
+		Type:       OperationProcessed,
+		Namespace:  namespace,
+		Operations: ops,
+	})
+}
+
+// dispatch queues the event for delivery to each subscription that matches any of the operations of the event.
+// Only the matching operations are delivered to a subscription.
+func (d *Dispatcher) dispatch(e *Event) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if len(d.subscriptions) == 0 {
+		return
+	}
+
+	id, err := random(idSize)
+	if err != nil {
+		logger.Errorf("Failed to generate ID of %s event: %s", e.Type, err)
+
+		return
+	}
+
+	e.ID = hex.EncodeToString(id)
+	e.Time = time.Now().UTC()
+
+	for _, sr := range d.subscriptions {
+		ops := sr.filter(e.Operations)
+		if len(ops) == 0 {
+			continue
+		}
+
+		event := *e
+		event.Operations = ops
+
+		select {
+		case sr.events <- &event:
+		default:
+			logger.Warnf("Delivery queue of webhook [%s] is full - dropping %s event [%s]", sr.ID, e.Type, e.ID)
+		}
+	}
+}
+
+// subscriber delivers the events of a subscription
+type subscriber struct {
+	*Subscription
+	dispatcher *Dispatcher
+	events     chan *Event
+	stopCh     chan struct{}
+}
+
+// filter returns the operations that match the filters of the subscription
+func (s *subscriber) filter(ops []*Operation) []*Operation {
+	var matching []*Operation
+
+	for _, op := range ops {
+		if len(s.Suffixes) > 0 && !contains(s.Suffixes, op.UniqueSuffix) {
+			continue
+		}
+
+		if len(s.OperationTypes) > 0 && !containsType(s.OperationTypes, op.Type) {
+			continue
+		}
+
+		matching = append(matching, op)
+	}
+
+	return matching
+}
+
+func (s *subscriber) deliverAll() {
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case e := <-s.events:
+			s.deliver(e)
+		}
+	}
+}
+
+// deliver posts the event to the endpoint of the subscription, retrying failed attempts with exponential backoff
+func (s *subscriber) deliver(e *Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		logger.Errorf("Failed to marshal %s event [%s]: %s", e.Type, e.ID, err)
+
+		return
+	}
+
+	signature := signaturePrefix + Sign(s.Secret, body)
+	interval := s.dispatcher.retryInterval
+
+	for attempt := 1; ; attempt++ {
+		err = s.post(e, body, signature, attempt)
+		if err == nil {
+			logger.Debugf("delivered %s event [%s] to webhook [%s]", e.Type, e.ID, s.ID)
+
+			return
+		}
+
+		if attempt >= s.dispatcher.maxAttempts {
+			logger.Errorf("Giving up delivering %s event [%s] to webhook [%s] after %d attempts: %s",
+				e.Type, e.ID, s.ID, attempt, err)
+
+			return
+		}
+
+		logger.Warnf("Failed to deliver %s event [%s] to webhook [%s] (attempt %d) - retrying in %s: %s",
+			e.Type, e.ID, s.ID, attempt, interval, err)
+
+		select {
+		case <-s.stopCh:
+			return
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
+
+func (s *subscriber) post(e *Event, body []byte, signature string, attempt int) error {
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(e.Type))
+	req.Header.Set(DeliveryHeader, e.ID)
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+
+	resp, err := s.dispatcher.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of the payload keyed with the given secret. Receivers verify a delivery
+// by comparing the signature header with "sha256=" followed by the signature of the request body.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload) //nolint:errcheck
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func random(size int) ([]byte, error) {
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsType(types []operation.Type, t operation.Type) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,282 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+)
+
+const namespace = "did:sidetree"
+
+func TestSubscribe(t *testing.T) {
+	d := New()
+
+	t.Run("success", func(t *testing.T) {
+		sub, err := d.Subscribe(&Subscription{URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+		require.NotEmpty(t, sub.ID)
+		require.NotEmpty(t, sub.Secret)
+		require.Equal(t, []string{"suffix1"}, sub.Suffixes)
+
+		sub2, err := d.Subscribe(&Subscription{URL: "http://localhost:8080", Secret: "secret",
+			OperationTypes: []operation.Type{operation.TypeCreate}})
+		require.NoError(t, err)
+		require.Equal(t, "secret", sub2.Secret)
+		require.NotEqual(t, sub.ID, sub2.ID)
+
+		subs := d.Subscriptions()
+		require.Len(t, subs, 2)
+		require.Equal(t, sub.ID, subs[0].ID)
+		require.Equal(t, sub2.ID, subs[1].ID)
+		require.Empty(t, subs[0].Secret)
+		require.Empty(t, subs[1].Secret)
+
+		require.True(t, d.Unsubscribe(sub.ID))
+		require.False(t, d.Unsubscribe(sub.ID))
+		require.Len(t, d.Subscriptions(), 1)
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		for _, u := range []string{"", "example.com/hook", "ftp://example.com", "https://", ":"} {
+			_, err := d.Subscribe(&Subscription{URL: u})
+			require.Error(t, err, u)
+			require.Contains(t, err.Error(), "must be an absolute http or https URL")
+		}
+	})
+
+	t.Run("invalid operation type", func(t *testing.T) {
+		_, err := d.Subscribe(&Subscription{URL: "https://example.com", OperationTypes: []operation.Type{"other"}})
+		require.EqualError(t, err, "operation type [other] is not supported")
+	})
+}
+
+func TestDispatch(t *testing.T) {
+	t.Run("events", func(t *testing.T) {
+		endpoint := newMockEndpoint(t)
+
+		d := New(WithLedger(mockLedger{0: "ref"}))
+
+		sub, err := d.Subscribe(&Subscription{URL: endpoint.URL, Secret: "secret"})
+		require.NoError(t, err)
+
+		d.OperationQueued(namespace, &operation.QueuedOperation{
+			UniqueSuffix:     "suffix1",
+			OperationRequest: []byte(`{"type":"create"}`),
+		})
+		d.BatchAnchored(namespace, "1.anchor", []*operation.Reference{
+			{UniqueSuffix: "suffix1", Type: operation.TypeCreate},
+		})
+		d.OperationsProcessed(namespace, []*operation.AnchoredOperation{
+			{UniqueSuffix: "suffix1", Type: operation.TypeCreate, TransactionNumber: 0, TransactionTime: 3},
+		})
+
+		requests := endpoint.wait(t, 3)
+
+		for i, eventType := range []EventType{OperationQueued, OperationAnchored, OperationProcessed} {
+			r := requests[i]
+			require.Equal(t, string(eventType), r.header.Get(EventHeader))
+			require.Equal(t, "application/json", r.header.Get("Content-Type"))
+			require.Equal(t, "1", r.header.Get(AttemptHeader))
+			require.Equal(t, "sha256="+Sign(sub.Secret, r.body), r.header.Get(SignatureHeader))
+			require.Equal(t, r.event.ID, r.header.Get(DeliveryHeader))
+
+			require.Equal(t, eventType, r.event.Type)
+			require.Equal(t, namespace, r.event.Namespace)
+			require.NotEmpty(t, r.event.ID)
+			require.False(t, r.event.Time.IsZero())
+			require.Len(t, r.event.Operations, 1)
+			require.Equal(t, namespace+":suffix1", r.event.Operations[0].DID)
+			require.Equal(t, operation.TypeCreate, r.event.Operations[0].Type)
+		}
+
+		require.Equal(t, "1.anchor", requests[1].event.Anchor)
+		require.Nil(t, requests[1].event.Operations[0].TransactionNumber)
+
+		processed := requests[2].event.Operations[0]
+		require.Equal(t, uint64(0), *processed.TransactionNumber)
+		require.Equal(t, uint64(3), *processed.TransactionTime)
+		require.Equal(t, "ref", processed.CanonicalReference)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		endpoint := newMockEndpoint(t)
+
+		d := New()
+
+		_, err := d.Subscribe(&Subscription{URL: endpoint.URL, Suffixes: []string{"suffix1", "suffix2"},
+			OperationTypes: []operation.Type{operation.TypeUpdate}})
+		require.NoError(t, err)
+
+		d.BatchAnchored(namespace, "1.anchor", []*operation.Reference{
+			{UniqueSuffix: "suffix1", Type: operation.TypeCreate},
+			{UniqueSuffix: "suffix3", Type: operation.TypeUpdate},
+		})
+		d.BatchAnchored(namespace, "2.anchor", []*operation.Reference{
+			{UniqueSuffix: "suffix1", Type: operation.TypeUpdate},
+			{UniqueSuffix: "suffix2", Type: operation.TypeCreate},
+			{UniqueSuffix: "suffix3", Type: operation.TypeUpdate},
+		})
+
+		requests := endpoint.wait(t, 1)
+		require.Equal(t, "2.anchor", requests[0].event.Anchor)
+		require.Len(t, requests[0].event.Operations, 1)
+		require.Equal(t, "suffix1", requests[0].event.Operations[0].UniqueSuffix)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, endpoint.get(), 1)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		endpoint := newMockEndpoint(t)
+		endpoint.failures = 2
+
+		d := New(WithRetryInterval(time.Millisecond), WithTimeout(time.Second))
+
+		_, err := d.Subscribe(&Subscription{URL: endpoint.URL})
+		require.NoError(t, err)
+
+		d.OperationQueued(namespace, &operation.QueuedOperation{UniqueSuffix: "suffix1"})
+
+		requests := endpoint.wait(t, 3)
+		require.Equal(t, "3", requests[2].header.Get(AttemptHeader))
+		require.Equal(t, requests[0].event.ID, requests[2].event.ID)
+		require.Empty(t, requests[0].event.Operations[0].Type)
+	})
+
+	t.Run("give up", func(t *testing.T) {
+		endpoint := newMockEndpoint(t)
+		endpoint.failures = 10
+
+		d := New(WithRetryInterval(time.Millisecond), WithMaxAttempts(2))
+
+		_, err := d.Subscribe(&Subscription{URL: endpoint.URL})
+		require.NoError(t, err)
+
+		d.OperationQueued(namespace, &operation.QueuedOperation{UniqueSuffix: "suffix1"})
+		d.OperationQueued(namespace, &operation.QueuedOperation{UniqueSuffix: "suffix2"})
+
+		// the second event is delivered after the first one was given up
+		requests := endpoint.wait(t, 4)
+		require.Equal(t, "suffix1", requests[1].event.Operations[0].UniqueSuffix)
+		require.Equal(t, "suffix2", requests[2].event.Operations[0].UniqueSuffix)
+	})
+
+	t.Run("unsubscribe stops retries", func(t *testing.T) {
+		endpoint := newMockEndpoint(t)
+		endpoint.failures = 10
+
+		d := New(WithRetryInterval(time.Hour))
+
+		sub, err := d.Subscribe(&Subscription{URL: endpoint.URL})
+		require.NoError(t, err)
+
+		d.OperationQueued(namespace, &operation.QueuedOperation{UniqueSuffix: "suffix1"})
+		endpoint.wait(t, 1)
+
+		require.True(t, d.Unsubscribe(sub.ID))
+
+		d.OperationQueued(namespace, &operation.QueuedOperation{UniqueSuffix: "suffix2"})
+
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, endpoint.get(), 1)
+	})
+
+	t.Run("no subscriptions", func(t *testing.T) {
+		New().OperationsProcessed(namespace, []*operation.AnchoredOperation{{UniqueSuffix: "suffix1"}})
+	})
+}
+
+func TestSign(t *testing.T) {
+	// RFC 4231 test case 2
+	require.Equal(t, "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		Sign("Jefe", []byte("what do ya want for nothing?")))
+}
+
+type request struct {
+	header http.Header
+	body   []byte
+	event  *Event
+}
+
+type mockEndpoint struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []*request
+	failures int
+}
+
+func newMockEndpoint(t *testing.T) *mockEndpoint {
+	t.Helper()
+
+	m := &mockEndpoint{}
+
+	m.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		event := &Event{}
+		require.NoError(t, json.Unmarshal(body, event))
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		m.requests = append(m.requests, &request{header: r.Header, body: body, event: event})
+
+		if m.failures > 0 {
+			m.failures--
+
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockEndpoint) get() []*request {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.requests
+}
+
+func (m *mockEndpoint) wait(t *testing.T, n int) []*request {
+	t.Helper()
+
+	require.Eventually(t, func() bool { return len(m.get()) >= n }, 5*time.Second, 10*time.Millisecond)
+
+	return m.get()
+}
+
+// mockLedger maps transaction numbers to canonical references
+type mockLedger map[uint64]string
+
+func (m mockLedger) Read(sinceTransactionNumber int) (bool, *txn.SidetreeTxn) {
+	number := uint64(sinceTransactionNumber + 1)
+
+	ref, ok := m[number]
+	if !ok {
+		return false, nil
+	}
+
+	return false, &txn.SidetreeTxn{TransactionNumber: number, CanonicalReference: ref}
+}